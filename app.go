@@ -0,0 +1,247 @@
+package ngrpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kanengo/goutil/pkg/log"
+	"github.com/kanengo/ngrpc/registry"
+	"github.com/kanengo/ngrpc/transport"
+	"go.uber.org/zap"
+)
+
+// App runs the servers, registers the service instance once they are listening
+// and stops them gracefully on the os signals.
+type App struct {
+	opts   options
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	instance *registry.ServiceInstance
+}
+
+func New(opts ...Option) *App {
+	o := options{
+		ctx:              context.Background(),
+		sigs:             []os.Signal{syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT},
+		registrarTimeout: 10 * time.Second,
+		stopTimeout:      10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.id == "" {
+		o.id = newID()
+	}
+	ctx, cancel := context.WithCancel(o.ctx)
+	return &App{
+		opts:   o,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (a *App) ID() string { return a.opts.id }
+
+func (a *App) Name() string { return a.opts.name }
+
+func (a *App) Version() string { return a.opts.version }
+
+func (a *App) Metadata() map[string]string { return a.opts.metadata }
+
+// Endpoint returns the registered endpoints, it is empty before Run.
+func (a *App) Endpoint() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.instance == nil {
+		return nil
+	}
+	return a.instance.Endpoints
+}
+
+// Run starts the servers and blocks until Stop is called, the context is done,
+// one of the signals is received or a server fails.
+func (a *App) Run() error {
+	// a signal received while starting is handled once started, so the instance is deregistered
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, a.opts.sigs...)
+	defer signal.Stop(sigCh)
+
+	instance, err := a.buildInstance()
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.instance = instance
+	a.mu.Unlock()
+
+	for _, fn := range a.opts.beforeStart {
+		if err = fn(a.ctx); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(a.opts.servers))
+	for _, srv := range a.opts.servers {
+		wg.Add(1)
+		go func(srv transport.Server) {
+			defer wg.Done()
+			if err := srv.Start(a.ctx); err != nil {
+				errCh <- err
+			}
+		}(srv)
+	}
+
+	if a.opts.registrar != nil {
+		rctx, rcancel := context.WithTimeout(a.ctx, a.opts.registrarTimeout)
+		err = a.opts.registrar.Register(rctx, instance)
+		rcancel()
+		if err != nil {
+			log.Error("[app] register failed", zap.String("instance", instance.String()), zap.Error(err))
+			a.stopServers()
+			wg.Wait()
+			return err
+		}
+	}
+
+	for _, fn := range a.opts.afterStart {
+		if err = fn(a.ctx); err != nil {
+			a.shutdown(false)
+			wg.Wait()
+			return err
+		}
+	}
+
+	var runErr error
+	select {
+	case <-a.ctx.Done():
+	case sig := <-sigCh:
+		log.Info("[app] received signal", zap.String("signal", sig.String()))
+	case runErr = <-errCh:
+		log.Error("[app] server failed", zap.Error(runErr))
+	}
+
+	if err = a.shutdown(runErr == nil); runErr == nil {
+		runErr = err
+	}
+	wg.Wait()
+
+	for _, fn := range a.opts.afterStop {
+		if err = fn(a.opts.ctx); err != nil && runErr == nil {
+			runErr = err
+		}
+	}
+	return runErr
+}
+
+// Stop makes Run deregister the instance and stop the servers.
+func (a *App) Stop() error {
+	a.cancel()
+	return nil
+}
+
+// shutdown runs the before stop hooks, deregisters the instance, waits the drain period
+// when drain and stops the servers.
+func (a *App) shutdown(drain bool) error {
+	var ret error
+	for _, fn := range a.opts.beforeStop {
+		if err := fn(a.opts.ctx); err != nil && ret == nil {
+			ret = err
+		}
+	}
+
+	a.mu.Lock()
+	instance := a.instance
+	a.mu.Unlock()
+	if a.opts.registrar != nil && instance != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), a.opts.registrarTimeout)
+		if err := a.opts.registrar.Deregister(ctx, instance); err != nil {
+			log.Error("[app] deregister failed", zap.String("instance", instance.String()), zap.Error(err))
+			if ret == nil {
+				ret = err
+			}
+		}
+		cancel()
+	}
+
+	if drain && a.opts.drainTimeout > 0 {
+		log.Info("[app] draining", zap.Duration("timeout", a.opts.drainTimeout))
+		time.Sleep(a.opts.drainTimeout)
+	}
+
+	if err := a.stopServers(); err != nil && ret == nil {
+		ret = err
+	}
+	return ret
+}
+
+func (a *App) stopServers() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.opts.stopTimeout)
+	defer cancel()
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		ret error
+	)
+	for _, srv := range a.opts.servers {
+		wg.Add(1)
+		go func(srv transport.Server) {
+			defer wg.Done()
+			if err := srv.Stop(ctx); err != nil {
+				mu.Lock()
+				if ret == nil {
+					ret = err
+				}
+				mu.Unlock()
+			}
+		}(srv)
+	}
+	wg.Wait()
+	return ret
+}
+
+// buildInstance collects the endpoints of the servers, which also makes them listen.
+func (a *App) buildInstance() (*registry.ServiceInstance, error) {
+	endpoints := make([]string, 0, len(a.opts.endpoints))
+	for _, e := range a.opts.endpoints {
+		endpoints = append(endpoints, e.String())
+	}
+	if len(endpoints) == 0 {
+		for _, srv := range a.opts.servers {
+			r, ok := srv.(transport.Endpointer)
+			if !ok {
+				continue
+			}
+			e, err := r.Endpoint()
+			if err != nil {
+				return nil, err
+			}
+			endpoints = append(endpoints, e.String())
+		}
+	}
+	return &registry.ServiceInstance{
+		ID:        a.opts.id,
+		Name:      a.opts.name,
+		Version:   a.opts.version,
+		Metadata:  a.opts.metadata,
+		Endpoints: endpoints,
+	}, nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		host, _ := os.Hostname()
+		return host
+	}
+	return hex.EncodeToString(b)
+}
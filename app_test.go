@@ -0,0 +1,172 @@
+package ngrpc
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"reflect"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/registry"
+)
+
+type testServer struct {
+	endpoint *url.URL
+	stopped  chan struct{}
+	once     sync.Once
+}
+
+func (s *testServer) Start(ctx context.Context) error {
+	<-s.stopped
+	return nil
+}
+
+func (s *testServer) Stop(ctx context.Context) error {
+	s.once.Do(func() { close(s.stopped) })
+	return nil
+}
+
+func (s *testServer) Endpoint() (*url.URL, error) {
+	return s.endpoint, nil
+}
+
+type testRegistrar struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *testRegistrar) Register(ctx context.Context, ins *registry.ServiceInstance) error {
+	r.add("register " + ins.Endpoints[0])
+	return nil
+}
+
+func (r *testRegistrar) Deregister(ctx context.Context, ins *registry.ServiceInstance) error {
+	r.add("deregister")
+	return nil
+}
+
+func (r *testRegistrar) add(e string) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+func TestApp(t *testing.T) {
+	srv := &testServer{
+		endpoint: &url.URL{Scheme: "grpc", Host: "127.0.0.1:9000"},
+		stopped:  make(chan struct{}),
+	}
+	r := &testRegistrar{}
+	hook := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			r.add(name)
+			return nil
+		}
+	}
+	app := New(
+		Name("helloworld"),
+		Version("v1.0.0"),
+		Server(srv),
+		Registrar(r),
+		DrainTimeout(50*time.Millisecond),
+		BeforeStart(hook("before start")),
+		AfterStart(hook("after start")),
+		BeforeStop(hook("before stop")),
+		AfterStop(hook("after stop")),
+	)
+	if app.ID() == "" {
+		t.Fatal("expect a generated id")
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		if got := app.Endpoint(); !reflect.DeepEqual(got, []string{"grpc://127.0.0.1:9000"}) {
+			t.Errorf("unexpected endpoints %v", got)
+		}
+		_ = app.Stop()
+	}()
+	start := time.Now()
+	if err := app.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Errorf("expect the drain timeout to be waited, run took %s", d)
+	}
+
+	want := []string{
+		"before start",
+		"register grpc://127.0.0.1:9000",
+		"after start",
+		"before stop",
+		"deregister",
+		"after stop",
+	}
+	if !reflect.DeepEqual(r.events, want) {
+		t.Errorf("expect %v, got %v", want, r.events)
+	}
+}
+
+func TestAppSignalDuringStart(t *testing.T) {
+	srv := &testServer{
+		endpoint: &url.URL{Scheme: "grpc", Host: "127.0.0.1:9000"},
+		stopped:  make(chan struct{}),
+	}
+	r := &testRegistrar{}
+	app := New(
+		Server(srv),
+		Registrar(r),
+		Signal(syscall.SIGUSR1),
+		BeforeStart(func(context.Context) error {
+			return syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		}),
+	)
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		_ = app.Stop()
+		t.Fatal("expect the signal received while starting to stop the app")
+	}
+	want := []string{"register grpc://127.0.0.1:9000", "deregister"}
+	if !reflect.DeepEqual(r.events, want) {
+		t.Errorf("expect %v, got %v", want, r.events)
+	}
+}
+
+// ctxServer only returns from Start when its context is done.
+type ctxServer struct {
+	testServer
+}
+
+func (s *ctxServer) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (s *ctxServer) Stop(ctx context.Context) error {
+	return nil
+}
+
+func TestAppStopCancelsServerContext(t *testing.T) {
+	srv := &ctxServer{testServer{endpoint: &url.URL{Scheme: "grpc", Host: "127.0.0.1:9000"}}}
+	app := New(Server(srv))
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+	time.Sleep(50 * time.Millisecond)
+	_ = app.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expect Stop to cancel the context the servers run on")
+	}
+}
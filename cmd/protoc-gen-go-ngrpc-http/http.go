@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contextPackage = protogen.GoImportPath("context")
+	httpPackage    = protogen.GoImportPath("github.com/kanengo/ngrpc/transport/http")
+)
+
+// methodDesc is one http binding of a method, a method has more than one
+// when its rule declares additional_bindings.
+type methodDesc struct {
+	method       *protogen.Method
+	num          int
+	httpMethod   string
+	path         string
+	body         string
+	bodyField    *protogen.Field
+	responseBody *protogen.Field
+}
+
+func (d *methodDesc) hasVars() bool {
+	return strings.Contains(d.path, "{")
+}
+
+func (d *methodDesc) handlerName() string {
+	return fmt.Sprintf("_%s_%s%d_HTTP_Handler", d.method.Parent.GoName, d.method.GoName, d.num)
+}
+
+func generateFile(gen *protogen.Plugin, file *protogen.File, omitempty bool) *protogen.GeneratedFile {
+	services := make([]*protogen.Service, 0, len(file.Services))
+	descs := make(map[*protogen.Service][]*methodDesc, len(file.Services))
+	for _, service := range file.Services {
+		ds, err := buildServiceDesc(service, omitempty)
+		if err != nil {
+			gen.Error(err)
+			return nil
+		}
+		if len(ds) == 0 {
+			continue
+		}
+		services = append(services, service)
+		descs[service] = ds
+	}
+	if len(services) == 0 {
+		return nil
+	}
+
+	filename := file.GeneratedFilenamePrefix + "_http.pb.go"
+	g := gen.NewGeneratedFile(filename, file.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-ngrpc-http. DO NOT EDIT.")
+	g.P("// versions:")
+	g.P("// - protoc-gen-go-ngrpc-http ", version)
+	g.P("// - protoc                   ", protocVersion(gen))
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+	g.P("// This is a compile-time assertion to ensure that this generated file")
+	g.P("// is compatible with the ngrpc package it is being compiled against.")
+	g.P("var _ = new(", contextPackage.Ident("Context"), ")")
+	g.P("var _ = ", httpPackage.Ident("NewServer"))
+	g.P()
+
+	for _, service := range services {
+		genService(g, service, descs[service])
+	}
+
+	return g
+}
+
+func buildServiceDesc(service *protogen.Service, omitempty bool) ([]*methodDesc, error) {
+	var descs []*methodDesc
+	for _, method := range service.Methods {
+		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			continue
+		}
+		rule, ok := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil || rule.Pattern == nil {
+			if omitempty {
+				continue
+			}
+			rule = &annotations.HttpRule{
+				Pattern: &annotations.HttpRule_Post{
+					Post: fmt.Sprintf("/%s/%s", service.Desc.FullName(), method.Desc.Name()),
+				},
+				Body: "*",
+			}
+		}
+		rules := append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...)
+		for _, r := range rules {
+			desc, err := buildMethodDesc(method, r, len(descs))
+			if err != nil {
+				return nil, err
+			}
+			descs = append(descs, desc)
+		}
+	}
+	return descs, nil
+}
+
+func buildMethodDesc(method *protogen.Method, rule *annotations.HttpRule, num int) (*methodDesc, error) {
+	desc := &methodDesc{
+		method: method,
+		num:    num,
+		body:   rule.GetBody(),
+	}
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		desc.httpMethod, desc.path = http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		desc.httpMethod, desc.path = http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		desc.httpMethod, desc.path = http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		desc.httpMethod, desc.path = http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		desc.httpMethod, desc.path = http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		desc.httpMethod, desc.path = strings.ToUpper(pattern.Custom.GetKind()), pattern.Custom.GetPath()
+	default:
+		return nil, fmt.Errorf("%s: unsupported http rule pattern %T", method.Desc.FullName(), pattern)
+	}
+
+	if desc.body != "" && desc.body != "*" {
+		desc.bodyField = findField(method.Input, desc.body)
+		if desc.bodyField == nil {
+			return nil, fmt.Errorf("%s: body field %q not found in %s", method.Desc.FullName(), desc.body, method.Input.Desc.FullName())
+		}
+	}
+	if rb := rule.GetResponseBody(); rb != "" {
+		desc.responseBody = findField(method.Output, rb)
+		if desc.responseBody == nil {
+			return nil, fmt.Errorf("%s: response body field %q not found in %s", method.Desc.FullName(), rb, method.Output.Desc.FullName())
+		}
+	}
+	return desc, nil
+}
+
+func findField(message *protogen.Message, name string) *protogen.Field {
+	for _, field := range message.Fields {
+		if string(field.Desc.Name()) == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func genService(g *protogen.GeneratedFile, service *protogen.Service, descs []*methodDesc) {
+	serverType := service.GoName + "HTTPServer"
+
+	g.P("const (")
+	seen := make(map[*protogen.Method]bool, len(descs))
+	for _, desc := range descs {
+		if seen[desc.method] {
+			continue
+		}
+		seen[desc.method] = true
+		g.P(operationName(desc.method), " = ", strconv.Quote(fullMethod(desc.method)))
+	}
+	g.P(")")
+	g.P()
+
+	g.P("type ", serverType, " interface {")
+	seen = make(map[*protogen.Method]bool, len(descs))
+	for _, desc := range descs {
+		m := desc.method
+		if seen[m] {
+			continue
+		}
+		seen[m] = true
+		g.P(m.Comments.Leading, m.GoName, "(", contextPackage.Ident("Context"), ", *", m.Input.GoIdent, ") (*", m.Output.GoIdent, ", error)")
+	}
+	g.P("}")
+	g.P()
+
+	g.P("func Register", serverType, "(s *", httpPackage.Ident("Server"), ", srv ", serverType, ") {")
+	for _, desc := range descs {
+		g.P("s.Route(", strconv.Quote(desc.httpMethod), ", ", strconv.Quote(desc.path), ", ", operationName(desc.method), ", ", desc.handlerName(), "(srv))")
+	}
+	g.P("}")
+	g.P()
+
+	for _, desc := range descs {
+		genHandler(g, serverType, desc)
+	}
+
+	genClient(g, service, descs)
+}
+
+func genHandler(g *protogen.GeneratedFile, serverType string, desc *methodDesc) {
+	m := desc.method
+	g.P("func ", desc.handlerName(), "(srv ", serverType, ") func(ctx ", httpPackage.Ident("Context"), ") error {")
+	g.P("return func(ctx ", httpPackage.Ident("Context"), ") error {")
+	g.P("var in ", m.Input.GoIdent)
+	if desc.body != "*" {
+		g.P("if err := ctx.BindQuery(&in); err != nil {")
+		g.P("return err")
+		g.P("}")
+	}
+	switch {
+	case desc.body == "*":
+		g.P("if err := ctx.Bind(&in); err != nil {")
+		g.P("return err")
+		g.P("}")
+	case desc.bodyField != nil:
+		f := desc.bodyField
+		if f.Message != nil && !f.Desc.IsList() && !f.Desc.IsMap() {
+			g.P("if in.", f.GoName, " == nil {")
+			g.P("in.", f.GoName, " = new(", f.Message.GoIdent, ")")
+			g.P("}")
+			g.P("if err := ctx.Bind(in.", f.GoName, "); err != nil {")
+		} else {
+			g.P("if err := ctx.Bind(&in.", f.GoName, "); err != nil {")
+		}
+		g.P("return err")
+		g.P("}")
+	}
+	if desc.hasVars() {
+		g.P("if err := ctx.BindVars(&in); err != nil {")
+		g.P("return err")
+		g.P("}")
+	}
+	g.P("h := ctx.Middleware(func(ctx ", contextPackage.Ident("Context"), ", req interface{}) (interface{}, error) {")
+	g.P("return srv.", m.GoName, "(ctx, req.(*", m.Input.GoIdent, "))")
+	g.P("})")
+	g.P("out, err := h(ctx, &in)")
+	g.P("if err != nil {")
+	g.P("return err")
+	g.P("}")
+	g.P("reply := out.(*", m.Output.GoIdent, ")")
+	if desc.responseBody != nil {
+		g.P("return ctx.Result(200, reply.", desc.responseBody.GoName, ")")
+	} else {
+		g.P("return ctx.Result(200, reply)")
+	}
+	g.P("}")
+	g.P("}")
+	g.P()
+}
+
+func genClient(g *protogen.GeneratedFile, service *protogen.Service, descs []*methodDesc) {
+	clientType := service.GoName + "HTTPClient"
+	implType := clientType + "Impl"
+
+	// the client calls each method through its first binding
+	var first []*methodDesc
+	seen := make(map[*protogen.Method]bool, len(descs))
+	for _, desc := range descs {
+		if !seen[desc.method] {
+			seen[desc.method] = true
+			first = append(first, desc)
+		}
+	}
+
+	g.P("type ", clientType, " interface {")
+	for _, desc := range first {
+		m := desc.method
+		g.P(m.GoName, "(ctx ", contextPackage.Ident("Context"), ", req *", m.Input.GoIdent, ", opts ...", httpPackage.Ident("CallOption"), ") (rsp *", m.Output.GoIdent, ", err error)")
+	}
+	g.P("}")
+	g.P()
+
+	g.P("type ", implType, " struct {")
+	g.P("cc *", httpPackage.Ident("Client"))
+	g.P("}")
+	g.P()
+
+	g.P("func New", clientType, "(client *", httpPackage.Ident("Client"), ") ", clientType, " {")
+	g.P("return &", implType, "{client}")
+	g.P("}")
+	g.P()
+
+	for _, desc := range first {
+		m := desc.method
+		g.P("func (c *", implType, ") ", m.GoName, "(ctx ", contextPackage.Ident("Context"), ", in *", m.Input.GoIdent, ", opts ...", httpPackage.Ident("CallOption"), ") (*", m.Output.GoIdent, ", error) {")
+		g.P("var out ", m.Output.GoIdent)
+		g.P("pattern := ", strconv.Quote(desc.path))
+		g.P("path := ", httpPackage.Ident("EncodeURL"), "(pattern, in, ", strconv.Quote(desc.body), ")")
+		g.P("opts = append(opts, ", httpPackage.Ident("Operation"), "(", operationName(m), "), ", httpPackage.Ident("PathTemplate"), "(pattern))")
+
+		args := "nil"
+		switch {
+		case desc.body == "*":
+			args = "in"
+		case desc.bodyField != nil:
+			args = "in." + desc.bodyField.GoName
+		}
+		reply := "&out"
+		if f := desc.responseBody; f != nil {
+			if f.Message != nil && !f.Desc.IsList() && !f.Desc.IsMap() {
+				g.P("out.", f.GoName, " = new(", f.Message.GoIdent, ")")
+				reply = "out." + f.GoName
+			} else {
+				reply = "&out." + f.GoName
+			}
+		}
+		g.P("err := c.cc.Invoke(ctx, ", strconv.Quote(desc.httpMethod), ", path, ", args, ", ", reply, ", opts...)")
+		g.P("if err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("return &out, nil")
+		g.P("}")
+		g.P()
+	}
+}
+
+func operationName(m *protogen.Method) string {
+	return "Operation" + m.Parent.GoName + m.GoName
+}
+
+func fullMethod(m *protogen.Method) string {
+	return fmt.Sprintf("/%s/%s", m.Parent.Desc.FullName(), m.Desc.Name())
+}
+
+func protocVersion(gen *protogen.Plugin) string {
+	v := gen.Request.GetCompilerVersion()
+	if v == nil {
+		return "(unknown)"
+	}
+	var suffix string
+	if s := v.GetSuffix(); s != "" {
+		suffix = "-" + s
+	}
+	return fmt.Sprintf("v%d.%d.%d%s", v.GetMajor(), v.GetMinor(), v.GetPatch(), suffix)
+}
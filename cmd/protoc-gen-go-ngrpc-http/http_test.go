@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// fixtureRequest is the request protoc sends for a library.proto declaring
+//
+//	service Library {
+//	  rpc GetBook(GetBookRequest) returns (Book) { option (google.api.http) = { get: "/v1/shelves/{shelf}/books/{name}" }; }
+//	  rpc CreateBook(CreateBookRequest) returns (Book) { option (google.api.http) = { post: "/v1/books" body: "book" }; }
+//	  rpc DeleteBook(GetBookRequest) returns (Book);
+//	}
+func fixtureRequest(t *testing.T) *pluginpb.CodeGeneratorRequest {
+	t.Helper()
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+			JsonName: proto.String(name),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	method := func(name, input string, rule *annotations.HttpRule) *descriptorpb.MethodDescriptorProto {
+		m := &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".library." + input),
+			OutputType: proto.String(".library.Book"),
+		}
+		if rule != nil {
+			m.Options = &descriptorpb.MethodOptions{}
+			proto.SetExtension(m.Options, annotations.E_Http, rule)
+		}
+		return m
+	}
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("library.proto"),
+		Package:    proto.String("library"),
+		Dependency: []string{"google/api/annotations.proto"},
+		Syntax:     proto.String("proto3"),
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("example.com/library;library")},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Book"), Field: []*descriptorpb.FieldDescriptorProto{field("name", 1, str, ""), field("title", 2, str, "")}},
+			{Name: proto.String("GetBookRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("shelf", 1, str, ""), field("name", 2, str, "")}},
+			{Name: proto.String("CreateBookRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("shelf", 1, str, ""),
+				field("book", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".library.Book"),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Library"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("GetBook", "GetBookRequest", &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/shelves/{shelf}/books/{name}"}}),
+				method("CreateBook", "CreateBookRequest", &annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/v1/books"}, Body: "book"}),
+				method("DeleteBook", "GetBookRequest", nil),
+			},
+		}},
+	}
+	return &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"library.proto"},
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+			protodesc.ToFileDescriptorProto(annotations.File_google_api_http_proto),
+			protodesc.ToFileDescriptorProto(annotations.File_google_api_annotations_proto),
+			fd,
+		},
+		CompilerVersion: &pluginpb.Version{Major: proto.Int32(3), Minor: proto.Int32(21), Patch: proto.Int32(10)},
+	}
+}
+
+func generate(t *testing.T, omitempty bool) string {
+	t.Helper()
+	gen, err := protogen.Options{}.New(fixtureRequest(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range gen.Files {
+		if f.Generate {
+			generateFile(gen, f, omitempty)
+		}
+	}
+	rsp := gen.Response()
+	if rsp.Error != nil {
+		t.Fatal(rsp.GetError())
+	}
+	if len(rsp.File) != 1 || rsp.File[0].GetName() != "library_http.pb.go" {
+		t.Fatalf("expected library_http.pb.go, got %v", rsp.File)
+	}
+	return rsp.File[0].GetContent()
+}
+
+func TestGenerateFile(t *testing.T) {
+	content := generate(t, true)
+	for _, want := range []string{
+		"// - protoc                   v3.21.10",
+		`= "/library.Library/GetBook"`,
+		"type LibraryHTTPServer interface {",
+		`s.Route("GET", "/v1/shelves/{shelf}/books/{name}", OperationLibraryGetBook, _Library_GetBook0_HTTP_Handler(srv))`,
+		`s.Route("POST", "/v1/books", OperationLibraryCreateBook, _Library_CreateBook1_HTTP_Handler(srv))`,
+		"if err := ctx.Bind(in.Book); err != nil {",
+		"if err := ctx.BindVars(&in); err != nil {",
+		"type LibraryHTTPClient interface {",
+		`path := http.EncodeURL(pattern, in, "book")`,
+		`err := c.cc.Invoke(ctx, "POST", path, in.Book, &out, opts...)`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected the generated code to contain %q\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "DeleteBook") {
+		t.Errorf("expected methods without annotation to be skipped")
+	}
+}
+
+func TestGenerateFileDefaultRoute(t *testing.T) {
+	content := generate(t, false)
+	want := `s.Route("POST", "/library.Library/DeleteBook", OperationLibraryDeleteBook, _Library_DeleteBook2_HTTP_Handler(srv))`
+	if !strings.Contains(content, want) {
+		t.Errorf("expected the generated code to contain %q\n%s", want, content)
+	}
+}
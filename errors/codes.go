@@ -0,0 +1,84 @@
+package errors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Error codes up to InternalErrMaxCode are HTTP status codes, anything above is a
+// business code defined by the service. The tables below keep gRPC and HTTP rendering
+// of the same Error consistent.
+
+var httpToGrpc = map[int32]codes.Code{
+	http.StatusOK:                  codes.OK,
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.Aborted,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	ClientClosedCode:               codes.Canceled,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+}
+
+var grpcToHTTP = map[codes.Code]int32{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           ClientClosedCode,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+}
+
+// GrpcCode converts an Error code into the grpc code sent on the wire.
+// Business codes are reported as FailedPrecondition.
+func GrpcCode(code int32) codes.Code {
+	if code > InternalErrMaxCode {
+		return codes.FailedPrecondition
+	}
+	if c, ok := httpToGrpc[code]; ok {
+		return c
+	}
+	switch {
+	case code >= 400 && code < 500:
+		return codes.FailedPrecondition
+	case code >= 500:
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// HTTPStatus converts an Error code into the http status of the response.
+// Business codes are reported as 400 Bad Request.
+func HTTPStatus(code int32) int {
+	if code > InternalErrMaxCode {
+		return http.StatusBadRequest
+	}
+	if code < 100 || code > 599 {
+		return http.StatusInternalServerError
+	}
+	return int(code)
+}
+
+// FromGrpcCode converts a grpc code into an Error code.
+func FromGrpcCode(code codes.Code) int32 {
+	if c, ok := grpcToHTTP[code]; ok {
+		return c
+	}
+	return UnknownCode
+}
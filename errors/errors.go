@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"net/http"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -28,8 +29,12 @@ func New(code int32, message string) *Error {
 	}
 }
 
+func Newf(code int32, format string, a ...any) *Error {
+	return New(code, fmt.Sprintf(format, a...))
+}
+
 func (e *Error) Error() string {
-	return fmt.Sprintf("error: code = %d message = %s metadata = %v", e.Code, e.Message, e.Metadata)
+	return fmt.Sprintf("error: code = %d reason = %s message = %s metadata = %v", e.Code, e.Reason, e.Message, e.Metadata)
 }
 
 func (e *Error) Internal() bool {
@@ -40,10 +45,86 @@ func (e *Error) Unwrap() error {
 	return e.cause
 }
 
+// Is matches each error in the chain with the target value by code,
+// and by reason too when the target has one.
+func (e *Error) Is(err error) bool {
+	if se := new(Error); errors.As(err, &se) {
+		return se.Code == e.Code && (se.Reason == "" || se.Reason == e.Reason)
+	}
+	return false
+}
+
+func (e *Error) WithReason(reason string) *Error {
+	err := e.Clone()
+	err.Reason = reason
+	return err
+}
+
+func (e *Error) WithCause(cause error) *Error {
+	err := e.Clone()
+	err.cause = cause
+	return err
+}
+
+func (e *Error) WithMetadata(md map[string]string) *Error {
+	err := e.Clone()
+	err.Metadata = md
+	return err
+}
+
+func (e *Error) Clone() *Error {
+	if e == nil {
+		return nil
+	}
+	var metadata map[string]string
+	if e.Metadata != nil {
+		metadata = make(map[string]string, len(e.Metadata))
+		for k, v := range e.Metadata {
+			metadata[k] = v
+		}
+	}
+	return &Error{
+		Status: Status{
+			Code:     e.Code,
+			Message:  e.Message,
+			Metadata: metadata,
+			Reason:   e.Reason,
+		},
+		cause: e.cause,
+	}
+}
+
+// ToGrpcStatus converts to a grpc status carrying the Status proto as detail,
+// so FromError can rebuild an identical Error on the other side.
 func (e *Error) ToGrpcStatus() *status.Status {
-	s := status.New(codes.Code(e.Code), e.Message)
+	s := status.New(e.grpcCode(), e.Message)
+	ds, err := s.WithDetails(&Status{
+		Code:     e.Code,
+		Message:  e.Message,
+		Metadata: e.Metadata,
+		Reason:   e.Reason,
+	})
+	if err != nil {
+		return s
+	}
+
+	return ds
+}
+
+// grpcCode keeps the code of the grpc status the error was built from,
+// several grpc codes share the same Error code and would not survive the round trip.
+func (e *Error) grpcCode() codes.Code {
+	if e.cause != nil {
+		if gs, ok := status.FromError(e.cause); ok && FromGrpcCode(gs.Code()) == e.Code {
+			return gs.Code()
+		}
+	}
+	return GrpcCode(e.Code)
+}
 
-	return s
+// GRPCStatus implements the interface recognized by grpc status.FromError.
+func (e *Error) GRPCStatus() *status.Status {
+	return e.ToGrpcStatus()
 }
 
 func FromError(err error) *Error {
@@ -58,13 +139,39 @@ func FromError(err error) *Error {
 		return New(UnknownCode, err.Error())
 	}
 
-	ret := New(int32(gs.Code()), gs.Message())
+	ret := New(FromGrpcCode(gs.Code()), gs.Message())
+
+	for _, detail := range gs.Details() {
+		switch d := detail.(type) {
+		case *Status:
+			ret = New(d.Code, d.Message).WithMetadata(d.Metadata).WithReason(d.Reason)
+			return ret.WithCause(err)
+		}
+	}
+
+	return ret.WithCause(err)
+}
+
+// Code returns the code of the error, nil error is http.StatusOK.
+func Code(err error) int32 {
+	if err == nil {
+		return http.StatusOK
+	}
+	return FromError(err).Code
+}
 
-	//for _, detail := range gs.Details() {
-	//	switch d := detail.(type) {
-	//
-	//	}
-	//}
+// Reason returns the reason of the error, empty when it has none.
+func Reason(err error) string {
+	if err == nil {
+		return ""
+	}
+	return FromError(err).Reason
+}
+
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
 
-	return ret
+func As(err error, target any) bool {
+	return errors.As(err, target)
 }
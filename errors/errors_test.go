@@ -0,0 +1,152 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrpcStatusRoundTrip(t *testing.T) {
+	want := New(1001, "user not found").WithMetadata(map[string]string{"uid": "42"})
+
+	err := want.ToGrpcStatus().Err()
+	got := FromError(err)
+	if got.Code != want.Code || got.Message != want.Message {
+		t.Errorf("expect %v, got %v", want, got)
+	}
+	if !reflect.DeepEqual(got.Metadata, want.Metadata) {
+		t.Errorf("expect %v, got %v", want.Metadata, got.Metadata)
+	}
+	if !errors.Is(got, New(1001, "")) {
+		t.Errorf("expect errors.Is matches code %d", want.Code)
+	}
+	if errors.Is(got, New(1002, "")) {
+		t.Errorf("expect errors.Is not matches code %d", 1002)
+	}
+}
+
+func TestGrpcCodeRoundTrip(t *testing.T) {
+	for c := codes.Canceled; c <= codes.Unauthenticated; c++ {
+		err := FromError(status.Error(c, "boom"))
+		if got := status.Code(err); got != c {
+			t.Errorf("expect %v, got %v", c, got)
+		}
+		// the code survives the wire and changes made by middleware
+		wire := FromError(err.WithReason("REASON").ToGrpcStatus().Err())
+		if got := status.Code(wire); got != c {
+			t.Errorf("expect %v over the wire, got %v", c, got)
+		}
+		if wire.Reason != "REASON" || wire.Code != FromGrpcCode(c) {
+			t.Errorf("expect reason and code %d kept, got %v", FromGrpcCode(c), wire)
+		}
+	}
+	// a cause with an unrelated code does not override the Error code
+	err := ServiceUnavailable("down").WithCause(status.Error(codes.NotFound, "missing"))
+	if got := status.Code(err); got != codes.Unavailable {
+		t.Errorf("expect %v, got %v", codes.Unavailable, got)
+	}
+}
+
+func TestFromError(t *testing.T) {
+	if FromError(nil) != nil {
+		t.Errorf("expect nil")
+	}
+
+	e := New(1001, "wrapped")
+	if got := FromError(fmt.Errorf("wrap: %w", e)); got != e {
+		t.Errorf("expect %v, got %v", e, got)
+	}
+
+	got := FromError(status.Error(codes.NotFound, "plain"))
+	if got.Message != "plain" {
+		t.Errorf("expect %s, got %s", "plain", got.Message)
+	}
+
+	got = FromError(errors.New("unknown"))
+	if got.Code != UnknownCode {
+		t.Errorf("expect %d, got %d", UnknownCode, got.Code)
+	}
+}
+
+func TestClone(t *testing.T) {
+	e := New(1001, "origin").WithMetadata(map[string]string{"a": "1"})
+	c := e.Clone()
+	c.Metadata["a"] = "2"
+	if e.Metadata["a"] != "1" {
+		t.Errorf("expect %s, got %s", "1", e.Metadata["a"])
+	}
+}
+
+func TestGrpcCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code int32
+		want codes.Code
+	}{
+		{name: "bad request", code: 400, want: codes.InvalidArgument},
+		{name: "service unavailable", code: 503, want: codes.Unavailable},
+		{name: "other 4xx", code: 418, want: codes.FailedPrecondition},
+		{name: "other 5xx", code: 502, want: codes.Internal},
+		{name: "business", code: InternalErrMaxCode + 1, want: codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GrpcCode(tt.code); got != tt.want {
+				t.Errorf("GrpcCode() = %v, want %v", got, tt.want)
+			}
+			if got := New(tt.code, "").ToGrpcStatus().Code(); got != tt.want {
+				t.Errorf("ToGrpcStatus().Code() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		code int32
+		want int
+	}{
+		{name: "not found", code: 404, want: 404},
+		{name: "business", code: InternalErrMaxCode + 1, want: 400},
+		{name: "invalid", code: 42, want: 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTTPStatus(tt.code); got != tt.want {
+				t.Errorf("HTTPStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeAndReason(t *testing.T) {
+	err := fmt.Errorf("wrap: %w", NotFound("user not found").WithReason("USER_NOT_FOUND"))
+	if Code(err) != 404 {
+		t.Errorf("expect %d, got %d", 404, Code(err))
+	}
+	if Reason(err) != "USER_NOT_FOUND" {
+		t.Errorf("expect %s, got %s", "USER_NOT_FOUND", Reason(err))
+	}
+	if !IsNotFound(err) || !Is(err, NotFound("")) || !Is(err, NotFound("").WithReason("USER_NOT_FOUND")) {
+		t.Errorf("expect %v is not found", err)
+	}
+	if Is(err, NotFound("").WithReason("ORDER_NOT_FOUND")) {
+		t.Errorf("expect reason mismatch")
+	}
+	if Code(nil) != 200 {
+		t.Errorf("expect %d, got %d", 200, Code(nil))
+	}
+	if got := Code(status.Error(codes.Unavailable, "")); got != 503 {
+		t.Errorf("expect %d, got %d", 503, got)
+	}
+
+	got := FromError(NotFound("user").WithReason("USER_NOT_FOUND").ToGrpcStatus().Err())
+	if got.Reason != "USER_NOT_FOUND" {
+		t.Errorf("expect %s, got %s", "USER_NOT_FOUND", got.Reason)
+	}
+}
@@ -1,9 +1,96 @@
 package errors
 
+import (
+	"net/http"
+)
+
+// ClientClosedCode is the non-standard http status used when the client cancels the request.
+const ClientClosedCode = 499
+
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, message)
+}
+
+func TooManyRequests(message string) *Error {
+	return New(http.StatusTooManyRequests, message)
+}
+
+func ClientClosed(message string) *Error {
+	return New(ClientClosedCode, message)
+}
+
+func InternalServer(message string) *Error {
+	return New(http.StatusInternalServerError, message)
+}
+
+func NotImplemented(message string) *Error {
+	return New(http.StatusNotImplemented, message)
+}
+
 func ServiceUnavailable(message string) *Error {
-	return New(503, message)
+	return New(http.StatusServiceUnavailable, message)
 }
 
-func BadRequest(message string) *Error {
-	return New(400, message)
+func Timeout(message string) *Error {
+	return New(http.StatusGatewayTimeout, message)
+}
+
+func IsBadRequest(err error) bool {
+	return Code(err) == http.StatusBadRequest
+}
+
+func IsUnauthorized(err error) bool {
+	return Code(err) == http.StatusUnauthorized
+}
+
+func IsForbidden(err error) bool {
+	return Code(err) == http.StatusForbidden
+}
+
+func IsNotFound(err error) bool {
+	return Code(err) == http.StatusNotFound
+}
+
+func IsConflict(err error) bool {
+	return Code(err) == http.StatusConflict
+}
+
+func IsTooManyRequests(err error) bool {
+	return Code(err) == http.StatusTooManyRequests
+}
+
+func IsClientClosed(err error) bool {
+	return Code(err) == ClientClosedCode
+}
+
+func IsInternalServer(err error) bool {
+	return Code(err) == http.StatusInternalServerError
+}
+
+func IsNotImplemented(err error) bool {
+	return Code(err) == http.StatusNotImplemented
+}
+
+func IsServiceUnavailable(err error) bool {
+	return Code(err) == http.StatusServiceUnavailable
+}
+
+func IsTimeout(err error) bool {
+	return Code(err) == http.StatusGatewayTimeout
 }
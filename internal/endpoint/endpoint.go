@@ -0,0 +1,31 @@
+package endpoint
+
+import (
+	"net/url"
+)
+
+func NewEndpoint(scheme, host string) *url.URL {
+	return &url.URL{Scheme: scheme, Host: host}
+}
+
+// ParseEndpoint returns the host of the first endpoint with the scheme, empty when none matches.
+func ParseEndpoint(endpoints []string, scheme string) (string, error) {
+	for _, e := range endpoints {
+		u, err := url.Parse(e)
+		if err != nil {
+			return "", err
+		}
+		if u.Scheme == scheme {
+			return u.Host, nil
+		}
+	}
+	return "", nil
+}
+
+// Scheme returns the secure variant of scheme, e.g. https, when isSecure.
+func Scheme(scheme string, isSecure bool) string {
+	if isSecure {
+		return scheme + "s"
+	}
+	return scheme
+}
@@ -0,0 +1,31 @@
+package endpoint
+
+import (
+	"testing"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints []string
+		scheme    string
+		want      string
+		wantErr   bool
+	}{
+		{name: "grpc", endpoints: []string{"http://127.0.0.1:8000", "grpc://127.0.0.1:9000"}, scheme: "grpc", want: "127.0.0.1:9000"},
+		{name: "https", endpoints: []string{"https://127.0.0.1:8443"}, scheme: Scheme("http", true), want: "127.0.0.1:8443"},
+		{name: "not found", endpoints: []string{"http://127.0.0.1:8000"}, scheme: "grpc", want: ""},
+		{name: "invalid", endpoints: []string{"%%"}, scheme: "grpc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEndpoint(tt.endpoints, tt.scheme)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseEndpoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
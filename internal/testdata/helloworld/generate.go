@@ -1,3 +1,3 @@
 package helloworld
 
-//go:generate protoc -I . -I ../../../third --go_out=paths=source_relative:. --go-grpc_out=paths=source_relative:. ./helloworld.proto
+//go:generate protoc -I . -I ../../../third --go_out=paths=source_relative:. --go-grpc_out=paths=source_relative:. --go-ngrpc-http_out=paths=source_relative:. ./helloworld.proto
@@ -0,0 +1,74 @@
+// Code generated by protoc-gen-go-ngrpc-http. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-ngrpc-http v0.1.0
+// - protoc                   v3.21.10
+// source: helloworld.proto
+
+package helloworld
+
+import (
+	context "context"
+	http "github.com/kanengo/ngrpc/transport/http"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the ngrpc package it is being compiled against.
+var _ = new(context.Context)
+var _ = http.NewServer
+
+const (
+	OperationGreeterSayHello = "/helloworld.Greeter/SayHello"
+)
+
+type GreeterHTTPServer interface {
+	SayHello(context.Context, *HelloRequest) (*HelloReply, error)
+}
+
+func RegisterGreeterHTTPServer(s *http.Server, srv GreeterHTTPServer) {
+	s.Route("GET", "/helloworld/{name}", OperationGreeterSayHello, _Greeter_SayHello0_HTTP_Handler(srv))
+}
+
+func _Greeter_SayHello0_HTTP_Handler(srv GreeterHTTPServer) func(ctx http.Context) error {
+	return func(ctx http.Context) error {
+		var in HelloRequest
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		if err := ctx.BindVars(&in); err != nil {
+			return err
+		}
+		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.SayHello(ctx, req.(*HelloRequest))
+		})
+		out, err := h(ctx, &in)
+		if err != nil {
+			return err
+		}
+		reply := out.(*HelloReply)
+		return ctx.Result(200, reply)
+	}
+}
+
+type GreeterHTTPClient interface {
+	SayHello(ctx context.Context, req *HelloRequest, opts ...http.CallOption) (rsp *HelloReply, err error)
+}
+
+type GreeterHTTPClientImpl struct {
+	cc *http.Client
+}
+
+func NewGreeterHTTPClient(client *http.Client) GreeterHTTPClient {
+	return &GreeterHTTPClientImpl{client}
+}
+
+func (c *GreeterHTTPClientImpl) SayHello(ctx context.Context, in *HelloRequest, opts ...http.CallOption) (*HelloReply, error) {
+	var out HelloReply
+	pattern := "/helloworld/{name}"
+	path := http.EncodeURL(pattern, in, "")
+	opts = append(opts, http.Operation(OperationGreeterSayHello), http.PathTemplate(pattern))
+	err := c.cc.Invoke(ctx, "GET", path, nil, &out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
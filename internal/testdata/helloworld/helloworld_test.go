@@ -0,0 +1,117 @@
+package helloworld_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/errors"
+	pb "github.com/kanengo/ngrpc/internal/testdata/helloworld"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/transport"
+	"github.com/kanengo/ngrpc/transport/grpc"
+	"github.com/kanengo/ngrpc/transport/http"
+)
+
+type greeter struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (g *greeter) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+	if in.Name == "error" {
+		return nil, errors.BadRequest(fmt.Sprintf("invalid argument %s", in.Name))
+	}
+	return &pb.HelloReply{Message: "Hello " + in.Name}, nil
+}
+
+// TestGreeter serves one implementation over grpc and http and calls it through both generated clients.
+func TestGreeter(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		kinds []transport.Kind
+	)
+	record := func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				mu.Lock()
+				kinds = append(kinds, tr.Kind())
+				mu.Unlock()
+			}
+			return handler(ctx, req)
+		}
+	}
+
+	g := &greeter{}
+	grpcSrv := grpc.NewServer(grpc.Address("127.0.0.1:0"), grpc.Middleware(record))
+	pb.RegisterGreeterServer(grpcSrv, g)
+	httpSrv := http.NewServer(http.Address("127.0.0.1:0"), http.Middleware(record))
+	pb.RegisterGreeterHTTPServer(httpSrv, g)
+
+	ctx := context.Background()
+	for _, srv := range []transport.Server{grpcSrv, httpSrv} {
+		go func(srv transport.Server) {
+			if err := srv.Start(ctx); err != nil {
+				t.Error(err)
+			}
+		}(srv)
+		defer func(srv transport.Server) { _ = srv.Stop(ctx) }(srv)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	grpcEndpoint, err := grpcSrv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.DialInsecure(ctx, grpc.WithEndpoint(grpcEndpoint.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	httpEndpoint, err := httpSrv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient, err := http.NewClient(ctx, http.WithEndpoint(httpEndpoint.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpClient.Close()
+
+	clients := map[string]func(context.Context, *pb.HelloRequest) (*pb.HelloReply, error){
+		"grpc": func(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+			return pb.NewGreeterClient(conn).SayHello(ctx, in)
+		},
+		"http": func(ctx context.Context, in *pb.HelloRequest) (*pb.HelloReply, error) {
+			return pb.NewGreeterHTTPClient(httpClient).SayHello(ctx, in)
+		},
+	}
+	for name, sayHello := range clients {
+		t.Run(name, func(t *testing.T) {
+			reply, err := sayHello(ctx, &pb.HelloRequest{Name: "ngrpc"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reply.Message != "Hello ngrpc" {
+				t.Errorf("expect %s, got %s", "Hello ngrpc", reply.Message)
+			}
+			if _, err = sayHello(ctx, &pb.HelloRequest{Name: "error"}); !errors.IsBadRequest(err) {
+				t.Errorf("expect bad request, got %v", err)
+			}
+		})
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := map[transport.Kind]int{transport.KindGRPC: 2, transport.KindHTTP: 2}
+	got := make(map[transport.Kind]int)
+	for _, k := range kinds {
+		got[k]++
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expect the calls handled by %v, got %v", want, got)
+	}
+}
@@ -60,3 +60,16 @@ func FromClientContext(ctx context.Context) (Metadata, bool) {
 	md, ok := ctx.Value(clientMetadataKey{}).(Metadata)
 	return md, ok
 }
+
+// AppendToClientContext returns a new context with the key value pairs merged into the client metadata.
+func AppendToClientContext(ctx context.Context, kv ...string) context.Context {
+	if len(kv)%2 == 1 {
+		panic("metadata: AppendToClientContext got an odd number of input pairs for metadata")
+	}
+	md, _ := FromClientContext(ctx)
+	md = md.Clone()
+	for i := 0; i < len(kv); i += 2 {
+		md.Set(kv[i], kv[i+1])
+	}
+	return NewClientContext(ctx, md)
+}
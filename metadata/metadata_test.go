@@ -172,3 +172,15 @@ func TestServerContext(t *testing.T) {
 		})
 	}
 }
+
+func TestAppendToClientContext(t *testing.T) {
+	ctx := NewClientContext(context.Background(), Metadata{"a": "1"})
+	ctx2 := AppendToClientContext(ctx, "b", "2")
+	md, _ := FromClientContext(ctx2)
+	if want := (Metadata{"a": "1", "b": "2"}); !reflect.DeepEqual(md, want) {
+		t.Errorf("expect %v, got %v", want, md)
+	}
+	if md, _ := FromClientContext(ctx); len(md) != 1 {
+		t.Errorf("expect the parent metadata unchanged, got %v", md)
+	}
+}
@@ -0,0 +1,194 @@
+package jwt
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/transport"
+)
+
+const (
+	authorizationKey = "Authorization"
+	bearerWord       = "Bearer"
+)
+
+var (
+	ErrMissingToken       = errors.Unauthorized("JWT token is missing").WithReason("TOKEN_MISSING")
+	ErrInvalidToken       = errors.Unauthorized("JWT token is invalid").WithReason("TOKEN_INVALID")
+	ErrTokenExpired       = errors.Unauthorized("JWT token has expired").WithReason("TOKEN_EXPIRED")
+	ErrSignToken          = errors.Unauthorized("can not sign JWT token").WithReason("TOKEN_SIGN_FAILED")
+	ErrMissingSigningKey  = errors.Unauthorized("JWT signing key is missing").WithReason("SIGNING_KEY_MISSING")
+	ErrWrongContext       = errors.Unauthorized("wrong context for middleware").WithReason("WRONG_CONTEXT")
+	defaultSigningMethods = []string{
+		"HS256", "HS384", "HS512",
+		"RS256", "RS384", "RS512",
+		"PS256", "PS384", "PS512",
+		"ES256", "ES384", "ES512",
+		"EdDSA",
+	}
+)
+
+type (
+	claimsKey struct{}
+	tokenKey  struct{}
+)
+
+// NewContext returns a context carrying the verified claims and the raw token.
+func NewContext(ctx context.Context, claims jwtv5.Claims, token string) context.Context {
+	ctx = context.WithValue(ctx, claimsKey{}, claims)
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// FromContext returns the claims verified by the server middleware.
+func FromContext(ctx context.Context) (jwtv5.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(jwtv5.Claims)
+	return claims, ok
+}
+
+// TokenFromContext returns the raw token verified by the server middleware.
+func TokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenKey{}).(string)
+	return token, ok
+}
+
+type Option func(*options)
+
+type options struct {
+	methods []string
+	claims  func() jwtv5.Claims
+	parser  []jwtv5.ParserOption
+
+	signingMethod jwtv5.SigningMethod
+	signingKey    any
+	kid           string
+	tokenClaims   func(ctx context.Context) jwtv5.Claims
+}
+
+// WithSigningMethods with the algorithms accepted by the server, defaults to the HMAC, RSA, ECDSA and EdDSA ones.
+func WithSigningMethods(methods ...string) Option {
+	return func(o *options) {
+		o.methods = methods
+	}
+}
+
+// WithClaims with the factory of the claims the server parses tokens into, defaults to jwt.MapClaims.
+func WithClaims(f func() jwtv5.Claims) Option {
+	return func(o *options) {
+		o.claims = f
+	}
+}
+
+// WithParserOptions with extra options of the server token parser, e.g. jwt.WithAudience.
+func WithParserOptions(opts ...jwtv5.ParserOption) Option {
+	return func(o *options) {
+		o.parser = append(o.parser, opts...)
+	}
+}
+
+// WithSigningKey makes the client sign a new token with key, kid is set in the token header when not empty.
+func WithSigningKey(method jwtv5.SigningMethod, key any, kid string) Option {
+	return func(o *options) {
+		o.signingMethod = method
+		o.signingKey = key
+		o.kid = kid
+	}
+}
+
+// WithTokenClaims with the claims of the tokens signed by the client.
+func WithTokenClaims(f func(ctx context.Context) jwtv5.Claims) Option {
+	return func(o *options) {
+		o.tokenClaims = f
+	}
+}
+
+// Server verifies the bearer token of the authorization header with the keys of keySet
+// and puts its claims in the context. Methods opt out by being left out of the selector
+// the middleware is added to the server with, e.g. s.AddMiddleware("/api.User/*", jwt.Server(keys)).
+func Server(keySet KeySet, opts ...Option) middleware.Middleware {
+	o := &options{
+		methods: defaultSigningMethods,
+		claims:  func() jwtv5.Claims { return jwtv5.MapClaims{} },
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	parserOpts := append([]jwtv5.ParserOption{jwtv5.WithValidMethods(o.methods)}, o.parser...)
+	parser := jwtv5.NewParser(parserOpts...)
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			auths := strings.SplitN(tr.RequestHeader().Get(authorizationKey), " ", 2)
+			if len(auths) != 2 || !strings.EqualFold(auths[0], bearerWord) {
+				return nil, ErrMissingToken
+			}
+			raw := auths[1]
+			token, err := parser.ParseWithClaims(raw, o.claims(), func(token *jwtv5.Token) (any, error) {
+				return keySet.Key(ctx, token)
+			})
+			if err != nil {
+				return nil, parseError(err)
+			}
+			if !token.Valid {
+				return nil, ErrInvalidToken
+			}
+			return handler(NewContext(ctx, token.Claims, raw), req)
+		}
+	}
+}
+
+func parseError(err error) error {
+	if errors.Is(err, jwtv5.ErrTokenExpired) || errors.Is(err, jwtv5.ErrTokenNotValidYet) {
+		return ErrTokenExpired.WithCause(err)
+	}
+	return ErrInvalidToken.WithCause(err)
+}
+
+// Client sets the authorization header of outgoing calls, with a token signed by the key
+// of WithSigningKey, or else the token the server middleware verified for the incoming call.
+func Client(opts ...Option) middleware.Middleware {
+	o := &options{
+		tokenClaims: func(context.Context) jwtv5.Claims {
+			return jwtv5.RegisteredClaims{ExpiresAt: jwtv5.NewNumericDate(time.Now().Add(time.Minute))}
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+			var raw string
+			if o.signingMethod != nil {
+				if o.signingKey == nil {
+					return nil, ErrMissingSigningKey
+				}
+				token := jwtv5.NewWithClaims(o.signingMethod, o.tokenClaims(ctx))
+				if o.kid != "" {
+					token.Header["kid"] = o.kid
+				}
+				signed, err := token.SignedString(o.signingKey)
+				if err != nil {
+					return nil, ErrSignToken.WithCause(err)
+				}
+				raw = signed
+			} else if token, ok := TokenFromContext(ctx); ok {
+				raw = token
+			}
+			if raw != "" {
+				tr.RequestHeader().Set(authorizationKey, bearerWord+" "+raw)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/transport"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string { return hc[key] }
+
+func (hc headerCarrier) Set(key, value string) { hc[key] = value }
+
+func (hc headerCarrier) Keys() []string { return nil }
+
+type testTransport struct {
+	header headerCarrier
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) FullMethod() string              { return "/api.User/Get" }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+func (tr *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func serverCtx(token string) context.Context {
+	header := headerCarrier{}
+	if token != "" {
+		header.Set(authorizationKey, bearerWord+" "+token)
+	}
+	return transport.NewServerContext(context.Background(), &testTransport{header: header})
+}
+
+func sign(t *testing.T, method jwtv5.SigningMethod, key any, kid string, claims jwtv5.Claims) string {
+	token := jwtv5.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestServer(t *testing.T) {
+	hmacKey := []byte("secret")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keys := NewRotatingKeySet(map[string]any{
+		"hmac": hmacKey,
+		"rsa":  &rsaKey.PublicKey,
+		"ec":   &ecKey.PublicKey,
+	})
+	claims := jwtv5.MapClaims{"sub": "kratos", "exp": time.Now().Add(time.Hour).Unix()}
+	expired := jwtv5.MapClaims{"sub": "kratos", "exp": time.Now().Add(-time.Hour).Unix()}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"hmac", sign(t, jwtv5.SigningMethodHS256, hmacKey, "hmac", claims), nil},
+		{"rsa", sign(t, jwtv5.SigningMethodRS256, rsaKey, "rsa", claims), nil},
+		{"ecdsa", sign(t, jwtv5.SigningMethodES256, ecKey, "ec", claims), nil},
+		{"missing", "", ErrMissingToken},
+		{"expired", sign(t, jwtv5.SigningMethodHS256, hmacKey, "hmac", expired), ErrTokenExpired},
+		{"unknown kid", sign(t, jwtv5.SigningMethodHS256, hmacKey, "old", claims), ErrInvalidToken},
+		{"wrong key", sign(t, jwtv5.SigningMethodHS256, []byte("other"), "hmac", claims), ErrInvalidToken},
+		// an RSA public key used as an HMAC secret must be rejected
+		{"alg confusion", sign(t, jwtv5.SigningMethodHS256, []byte("x"), "rsa", claims), ErrInvalidToken},
+		{"none", sign(t, jwtv5.SigningMethodNone, jwtv5.UnsafeAllowNoneSignatureType, "hmac", claims), ErrInvalidToken},
+	}
+
+	h := Server(keys)(func(ctx context.Context, req any) (any, error) {
+		c, ok := FromContext(ctx)
+		if !ok {
+			t.Fatal("expect claims in context")
+		}
+		sub, _ := c.GetSubject()
+		return sub, nil
+	})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reply, err := h(serverCtx(test.token), nil)
+			if test.want == nil {
+				if err != nil || reply != "kratos" {
+					t.Errorf("expect kratos, got %v %v", reply, err)
+				}
+				return
+			}
+			if !errors.Is(err, test.want) || !errors.IsUnauthorized(err) {
+				t.Errorf("expect %v, got %v", test.want, err)
+			}
+		})
+	}
+
+	// rotating out a key invalidates the tokens it signed
+	token := sign(t, jwtv5.SigningMethodHS256, hmacKey, "hmac", claims)
+	keys.Delete("hmac")
+	if _, err := h(serverCtx(token), nil); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expect %v, got %v", ErrInvalidToken, err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	key := []byte("secret")
+	header := headerCarrier{}
+	ctx := transport.NewClientContext(context.Background(), &testTransport{header: header})
+
+	next := func(ctx context.Context, req any) (any, error) { return nil, nil }
+	if _, err := Client(WithSigningKey(jwtv5.SigningMethodHS256, key, "v1"))(next)(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Server(StaticKey(key))(next)(serverCtx(header.Get(authorizationKey)[len(bearerWord)+1:]), nil)
+	if err != nil {
+		t.Errorf("expect the signed token verified, got %v", err)
+	}
+
+	// without a signing key the incoming token is forwarded
+	header = headerCarrier{}
+	ctx = NewContext(transport.NewClientContext(context.Background(), &testTransport{header: header}), jwtv5.MapClaims{}, "incoming")
+	if _, err := Client()(next)(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get(authorizationKey); got != "Bearer incoming" {
+		t.Errorf("expect %s, got %s", "Bearer incoming", got)
+	}
+}
@@ -0,0 +1,66 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet provides the key verifying a token, e.g. looked up by its kid header.
+type KeySet interface {
+	Key(ctx context.Context, token *jwtv5.Token) (any, error)
+}
+
+// KeySetFunc adapts a function to KeySet.
+type KeySetFunc func(ctx context.Context, token *jwtv5.Token) (any, error)
+
+func (f KeySetFunc) Key(ctx context.Context, token *jwtv5.Token) (any, error) {
+	return f(ctx, token)
+}
+
+// StaticKey returns a KeySet verifying every token with key.
+func StaticKey(key any) KeySet {
+	return KeySetFunc(func(context.Context, *jwtv5.Token) (any, error) {
+		return key, nil
+	})
+}
+
+// RotatingKeySet holds the keys by kid, keys can be added and removed while serving
+// so that tokens signed with the previous key stay valid during a rotation.
+type RotatingKeySet struct {
+	mu   sync.RWMutex
+	keys map[string]any
+}
+
+func NewRotatingKeySet(keys map[string]any) *RotatingKeySet {
+	s := &RotatingKeySet{keys: make(map[string]any, len(keys))}
+	for kid, key := range keys {
+		s.keys[kid] = key
+	}
+	return s
+}
+
+func (s *RotatingKeySet) Set(kid string, key any) {
+	s.mu.Lock()
+	s.keys[kid] = key
+	s.mu.Unlock()
+}
+
+func (s *RotatingKeySet) Delete(kid string) {
+	s.mu.Lock()
+	delete(s.keys, kid)
+	s.mu.Unlock()
+}
+
+func (s *RotatingKeySet) Key(_ context.Context, token *jwtv5.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
@@ -0,0 +1,74 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Status is a breaker of a group as reported by the admin handler.
+type Status struct {
+	Key     string `json:"key"`
+	State   string `json:"state"`
+	Forced  bool   `json:"forced"`
+	Success int64  `json:"success"`
+	Total   int64  `json:"total"`
+}
+
+// Statuses returns the status of the managed breakers of the group.
+func (g *Group) Statuses() []Status {
+	var statuses []Status
+	g.Range(func(key string, b Breaker) bool {
+		if mb, ok := b.(ManagedBreaker); ok {
+			success, total := mb.Stats()
+			statuses = append(statuses, Status{
+				Key:     key,
+				State:   StateString(mb.State()),
+				Forced:  mb.Forced(),
+				Success: success,
+				Total:   total,
+			})
+		}
+		return true
+	})
+	return statuses
+}
+
+// Handler serves the breakers of the group, GET lists them and POST with the key and
+// action (open, close or reset) query parameters overrides one of them, e.g.
+// httpServer.Handle("/debug/breakers", group.Handler()).
+func (g *Group) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			key := r.URL.Query().Get("key")
+			b, ok := g.Lookup(key)
+			if !ok {
+				http.Error(w, "breaker not found: "+key, http.StatusNotFound)
+				return
+			}
+			mb, ok := b.(ManagedBreaker)
+			if !ok {
+				http.Error(w, "breaker can not be controlled: "+key, http.StatusNotImplemented)
+				return
+			}
+			switch action := r.URL.Query().Get("action"); action {
+			case "open":
+				mb.ForceOpen()
+			case "close":
+				mb.ForceClose()
+			case "reset":
+				mb.Reset()
+			default:
+				http.Error(w, "invalid action: "+action, http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(g.Statuses())
+	})
+}
@@ -0,0 +1,92 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type managedBreaker struct {
+	testBreaker
+	state     int32
+	forced    bool
+	listeners []func(from, to int32)
+}
+
+func (b *managedBreaker) State() int32 { return b.state }
+
+func (b *managedBreaker) Stats() (int64, int64) {
+	return int64(b.success), int64(b.success + b.failure)
+}
+
+func (b *managedBreaker) Subscribe(fn func(from, to int32)) { b.listeners = append(b.listeners, fn) }
+
+func (b *managedBreaker) ForceOpen() { b.set(StateOpened, true) }
+
+func (b *managedBreaker) ForceClose() { b.set(StateClosed, true) }
+
+func (b *managedBreaker) Reset() { b.set(StateClosed, false) }
+
+func (b *managedBreaker) set(state int32, forced bool) {
+	from := b.state
+	b.state, b.forced = state, forced
+	if from != state {
+		for _, fn := range b.listeners {
+			fn(from, state)
+		}
+	}
+}
+
+func (b *managedBreaker) Forced() bool { return b.forced }
+
+func TestHandler(t *testing.T) {
+	g := NewGroup(0, func() Breaker { return &managedBreaker{} })
+	g.Get("discovery:///helloworld/helloworld.Greeter/SayHello").MarkSuccess()
+	h := g.Handler()
+	collector := NewCollector(g, "ngrpc")
+
+	do := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	w := do(http.MethodPost, "/?action=open&key=discovery:///helloworld/helloworld.Greeter/SayHello")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expect %d, got %d %s", http.StatusOK, w.Code, w.Body)
+	}
+	var statuses []Status
+	if err := json.NewDecoder(w.Body).Decode(&statuses); err != nil {
+		t.Fatal(err)
+	}
+	want := Status{Key: "discovery:///helloworld/helloworld.Greeter/SayHello", State: "open", Forced: true, Success: 1, Total: 1}
+	if len(statuses) != 1 || statuses[0] != want {
+		t.Errorf("expect %v, got %v", want, statuses)
+	}
+
+	if w := do(http.MethodPost, "/?action=open&key=unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("expect %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := do(http.MethodPost, "/?action=half&key="+want.Key); w.Code != http.StatusBadRequest {
+		t.Errorf("expect %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := do(http.MethodGet, "/"); !strings.Contains(w.Body.String(), `"state":"open"`) {
+		t.Errorf("unexpected body %s", w.Body)
+	}
+
+	expected := `
+# HELP ngrpc_circuit_breaker_state The state of the circuit breaker.
+# TYPE ngrpc_circuit_breaker_state gauge
+ngrpc_circuit_breaker_state{key="discovery:///helloworld/helloworld.Greeter/SayHello"} 1
+# HELP ngrpc_circuit_breaker_transitions_total The state changes of the circuit breaker.
+# TYPE ngrpc_circuit_breaker_transitions_total counter
+ngrpc_circuit_breaker_transitions_total{from="closed",key="discovery:///helloworld/helloworld.Greeter/SayHello",to="open"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "ngrpc_circuit_breaker_state", "ngrpc_circuit_breaker_transitions_total"); err != nil {
+		t.Error(err)
+	}
+}
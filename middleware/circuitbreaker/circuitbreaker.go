@@ -1,8 +1,18 @@
 package circuitbreaker
 
+import (
+	"context"
+	stderrors "errors"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/transport"
+)
+
 const (
-	StateOpened = 1
-	StateClosed = 0
+	StateOpened     = 1
+	StateClosed     = 0
+	StateHalfOpened = 2
 )
 
 type Breaker interface {
@@ -10,3 +20,141 @@ type Breaker interface {
 	MarkSuccess()
 	MarkFailed()
 }
+
+// RejectRecorder is implemented by the breakers counting the calls they reject, e.g. the
+// SRE breaker whose drop ratio grows with the throttled requests. The other breakers
+// only see the results of the calls they allowed.
+type RejectRecorder interface {
+	MarkRejected()
+}
+
+// CancelRecorder is implemented by the breakers told about the calls canceled by the caller,
+// which are neither successes nor failures, e.g. to let another half-open probe through.
+type CancelRecorder interface {
+	MarkCanceled()
+}
+
+// ManagedBreaker is a Breaker whose state can be observed and overridden by operators.
+type ManagedBreaker interface {
+	Breaker
+	State() int32
+	// Stats returns the successful and total calls of the rolling window.
+	Stats() (success, total int64)
+	// Subscribe registers fn to be called after every state change.
+	Subscribe(fn func(from, to int32))
+	// ForceOpen rejects every call until Reset.
+	ForceOpen()
+	// ForceClose allows every call until Reset.
+	ForceClose()
+	// Reset closes a forced breaker and resumes the automatic transitions.
+	Reset()
+	Forced() bool
+}
+
+func StateString(state int32) string {
+	switch state {
+	case StateOpened:
+		return "open"
+	case StateClosed:
+		return "closed"
+	case StateHalfOpened:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// ReasonNotAllowed is the reason of the error returned when the breaker rejects a call.
+const ReasonNotAllowed = "CIRCUIT_BREAKER_OPEN"
+
+var ErrNotAllowed = errors.ServiceUnavailable("request failed due to circuit breaker triggered").WithReason(ReasonNotAllowed)
+
+// Classifier reports whether err counts as a failure of the callee.
+type Classifier func(err error) bool
+
+// DefaultClassifier marks 5xx codes, which include the transport errors, as failures.
+// The calls canceled by the caller are never classified, Client does not report them.
+func DefaultClassifier(err error) bool {
+	code := errors.Code(err)
+	return code >= 500 && code < 600
+}
+
+type Option func(*options)
+
+type options struct {
+	classifier Classifier
+	size       int
+	group      *Group
+}
+
+// WithClassifier with the classifier of the failed calls.
+func WithClassifier(c Classifier) Option {
+	return func(o *options) {
+		o.classifier = c
+	}
+}
+
+// WithGroupSize with the maximum number of breakers kept, the least recently used one is dropped beyond it.
+func WithGroupSize(size int) Option {
+	return func(o *options) {
+		o.size = size
+	}
+}
+
+// WithGroup with the group holding the breakers, e.g. to expose them through Group.Handler,
+// the breakers are then created by the factory of g instead of the one passed to Client.
+func WithGroup(g *Group) Option {
+	return func(o *options) {
+		o.group = g
+	}
+}
+
+// Client rejects calls with ErrNotAllowed while the breaker of their target and method is open,
+// the breakers are created by newBreaker on the first call.
+func Client(newBreaker func() Breaker, opts ...Option) middleware.Middleware {
+	o := &options{
+		classifier: DefaultClassifier,
+		size:       1024,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	g := o.group
+	if g == nil {
+		g = NewGroup(o.size, newBreaker)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			breaker := g.Get(tr.Endpoint() + tr.FullMethod())
+			if err := breaker.Allow(); err != nil {
+				if r, ok := breaker.(RejectRecorder); ok {
+					r.MarkRejected()
+				}
+				return nil, ErrNotAllowed.WithCause(err)
+			}
+			reply, err := handler(ctx, req)
+			switch {
+			case canceled(ctx, err):
+				if r, ok := breaker.(CancelRecorder); ok {
+					r.MarkCanceled()
+				}
+			case err != nil && o.classifier(err):
+				breaker.MarkFailed()
+			default:
+				breaker.MarkSuccess()
+			}
+			return reply, err
+		}
+	}
+}
+
+// canceled reports whether the call failed because the caller gave up on it.
+func canceled(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.IsClientClosed(err) || stderrors.Is(err, context.Canceled) || stderrors.Is(ctx.Err(), context.Canceled)
+}
@@ -0,0 +1,143 @@
+package circuitbreaker
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/transport"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string { return hc[key] }
+
+func (hc headerCarrier) Set(key, value string) { hc[key] = value }
+
+func (hc headerCarrier) Keys() []string { return nil }
+
+type testTransport struct {
+	method string
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *testTransport) Endpoint() string                { return "discovery:///helloworld" }
+func (tr *testTransport) FullMethod() string              { return tr.method }
+func (tr *testTransport) RequestHeader() transport.Header { return headerCarrier{} }
+func (tr *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+type testBreaker struct {
+	open                       bool
+	success, failure, rejected int
+}
+
+func (b *testBreaker) Allow() error {
+	if b.open {
+		return stderrors.New("open")
+	}
+	return nil
+}
+
+func (b *testBreaker) MarkSuccess() { b.success++ }
+
+func (b *testBreaker) MarkFailed() { b.failure++ }
+
+type recordingBreaker struct{ testBreaker }
+
+func (b *recordingBreaker) MarkRejected() { b.rejected++ }
+
+func TestClient(t *testing.T) {
+	breakers := make(map[*testBreaker]bool)
+	var last *testBreaker
+	m := Client(func() Breaker {
+		last = &testBreaker{}
+		breakers[last] = true
+		return last
+	})
+	call := func(method string, err error) error {
+		ctx := transport.NewClientContext(context.Background(), &testTransport{method: method})
+		_, rerr := m(func(ctx context.Context, req any) (any, error) {
+			return nil, err
+		})(ctx, nil)
+		return rerr
+	}
+
+	_ = call("/helloworld.Greeter/SayHello", nil)
+	b := last
+	_ = call("/helloworld.Greeter/SayHello", errors.BadRequest("bad"))
+	_ = call("/helloworld.Greeter/SayHello", errors.ServiceUnavailable("down"))
+	_ = call("/helloworld.Greeter/SayHello", stderrors.New("connection refused"))
+	if b.success != 2 || b.failure != 2 {
+		t.Errorf("expect 2 successes and 2 failures, got %d %d", b.success, b.failure)
+	}
+	_ = call("/helloworld.Greeter/SayHello", errors.ClientClosed("canceled"))
+	_ = call("/helloworld.Greeter/SayHello", context.Canceled)
+	if b.success != 2 || b.failure != 2 {
+		t.Errorf("expect the canceled calls not marked, got %d %d", b.success, b.failure)
+	}
+
+	b.open = true
+	err := call("/helloworld.Greeter/SayHello", nil)
+	if !errors.IsServiceUnavailable(err) || errors.Reason(err) != ReasonNotAllowed {
+		t.Errorf("expect %v, got %v", ErrNotAllowed, err)
+	}
+	if b.success != 2 || b.failure != 2 {
+		t.Errorf("expect the rejected call not marked, got %d %d", b.success, b.failure)
+	}
+
+	_ = call("/helloworld.Greeter/SayHi", nil)
+	if len(breakers) != 2 || last == b {
+		t.Errorf("expect a breaker per method, got %d", len(breakers))
+	}
+}
+
+func TestClientRejectRecorder(t *testing.T) {
+	b := &recordingBreaker{testBreaker{open: true}}
+	m := Client(func() Breaker { return b })
+	ctx := transport.NewClientContext(context.Background(), &testTransport{method: "/helloworld.Greeter/SayHello"})
+	_, _ = m(func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	})(ctx, nil)
+	if b.rejected != 1 || b.failure != 0 {
+		t.Errorf("expect the rejection recorded, got %d rejected %d failed", b.rejected, b.failure)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	g := NewGroup(2, func() Breaker { return &testBreaker{} })
+	a := g.Get("a")
+	g.Get("b")
+	g.Get("a")
+	g.Get("c")
+	if g.Get("a") != a {
+		t.Error("expect the recently used breaker kept")
+	}
+	if _, ok := g.items["b"]; ok {
+		t.Error("expect the least recently used breaker dropped")
+	}
+}
+
+func TestGroupKeepsManaged(t *testing.T) {
+	g := NewGroup(2, func() Breaker { return &managedBreaker{} })
+	g.Get("forced").(ManagedBreaker).ForceClose()
+	g.Get("open").(*managedBreaker).state = StateOpened
+	g.Get("closed")
+	if g.lru.Len() != 3 {
+		t.Fatalf("expect the forced and open breakers kept, got %d breakers", g.lru.Len())
+	}
+
+	g.Get("open").(ManagedBreaker).Reset()
+	g.Get("other")
+	if _, ok := g.Lookup("forced"); !ok {
+		t.Error("expect the forced breaker kept")
+	}
+	for _, key := range []string{"closed", "open"} {
+		if _, ok := g.Lookup(key); ok {
+			t.Errorf("expect the closed breaker %s dropped", key)
+		}
+	}
+	if g.lru.Len() != 2 {
+		t.Errorf("expect %d breakers, got %d", 2, g.lru.Len())
+	}
+}
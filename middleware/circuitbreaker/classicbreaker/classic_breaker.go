@@ -0,0 +1,287 @@
+package classicbreaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kanengo/goutil/pkg/metric"
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware/circuitbreaker"
+)
+
+var (
+	ErrOpen = errors.ServiceUnavailable("service unavailable")
+
+	_ circuitbreaker.ManagedBreaker = (*classicBreaker)(nil)
+	_ circuitbreaker.CancelRecorder = (*classicBreaker)(nil)
+)
+
+// classicBreaker is a closed, open and half-open breaker. It opens on consecutive failures or on
+// the failure ratio of the rolling window, rejects calls until the open timeout has passed, then
+// lets a limited number of probes through and closes once they all succeed. While half-open,
+// only the results of the admitted probes count, the others are ignored.
+type classicBreaker struct {
+	c Config
+
+	mu          sync.Mutex
+	stat        metric.RollingCounter
+	state       int32
+	consecutive int64
+	openedAt    time.Time
+	probes      int64
+	pending     int64
+	probeOK     int64
+	forced      bool
+	listeners   []func(from, to int32)
+}
+
+type Config struct {
+	// ConsecutiveFailures opens the breaker after that many failures in a row, negative disables it.
+	ConsecutiveFailures int64
+	// FailureRatio opens the breaker when the failures of the window reach that ratio, negative disables it.
+	FailureRatio float64
+	// MinRequests is the number of calls in the window before FailureRatio applies.
+	MinRequests int64
+	// OpenTimeout is how long the breaker stays open before the half-open probes.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of calls let through while half-open, all must succeed to close.
+	HalfOpenProbes int64
+
+	Bucket int
+	Window time.Duration
+
+	// OnStateChange is called after every transition, outside of the breaker lock,
+	// more listeners can be added with Subscribe.
+	OnStateChange func(from, to int32)
+}
+
+func (c *Config) fix() {
+	if c.ConsecutiveFailures == 0 {
+		c.ConsecutiveFailures = 5
+	}
+	if c.FailureRatio == 0 {
+		c.FailureRatio = 0.5
+	}
+	if c.MinRequests == 0 {
+		c.MinRequests = 20
+	}
+	if c.OpenTimeout == 0 {
+		c.OpenTimeout = 5 * time.Second
+	}
+	if c.HalfOpenProbes == 0 {
+		c.HalfOpenProbes = 1
+	}
+	if c.Bucket == 0 {
+		c.Bucket = 10
+	}
+	if c.Window == 0 {
+		c.Window = 10 * time.Second
+	}
+}
+
+func New(c *Config) circuitbreaker.ManagedBreaker {
+	if c == nil {
+		c = &Config{}
+	}
+	c.fix()
+	b := &classicBreaker{
+		c:     *c,
+		state: circuitbreaker.StateClosed,
+	}
+	if c.OnStateChange != nil {
+		b.listeners = append(b.listeners, c.OnStateChange)
+	}
+	b.stat = b.newStat()
+	return b
+}
+
+func (b *classicBreaker) newStat() metric.RollingCounter {
+	return metric.NewRollingCounter(metric.RollingCounterOpts{
+		Size:           b.c.Bucket,
+		BucketDuration: b.c.Window / time.Duration(b.c.Bucket),
+	})
+}
+
+// State returns the current state, one of the circuitbreaker State constants.
+func (b *classicBreaker) State() int32 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *classicBreaker) Allow() error {
+	b.mu.Lock()
+	from := b.state
+	var err error
+	switch b.state {
+	case circuitbreaker.StateOpened:
+		if b.forced {
+			err = ErrOpen
+			break
+		}
+		if time.Since(b.openedAt) < b.c.OpenTimeout {
+			err = ErrOpen
+			break
+		}
+		b.setState(circuitbreaker.StateHalfOpened)
+		b.probes = 1
+		b.pending = 1
+	case circuitbreaker.StateHalfOpened:
+		if b.probes >= b.c.HalfOpenProbes {
+			err = ErrOpen
+			break
+		}
+		b.probes++
+		b.pending++
+	}
+	to, listeners := b.state, b.listeners
+	b.mu.Unlock()
+
+	notify(listeners, from, to)
+	return err
+}
+
+func (b *classicBreaker) MarkSuccess() {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case circuitbreaker.StateClosed:
+		b.consecutive = 0
+		b.stat.Add(1)
+	case circuitbreaker.StateHalfOpened:
+		if b.pending == 0 {
+			break
+		}
+		b.pending--
+		b.probeOK++
+		if b.probeOK >= b.c.HalfOpenProbes {
+			b.setState(circuitbreaker.StateClosed)
+		}
+	}
+	to, listeners := b.state, b.listeners
+	b.mu.Unlock()
+
+	notify(listeners, from, to)
+}
+
+func (b *classicBreaker) MarkFailed() {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case circuitbreaker.StateClosed:
+		b.consecutive++
+		b.stat.Add(0)
+		if !b.forced && b.shouldTrip() {
+			b.setState(circuitbreaker.StateOpened)
+		}
+	case circuitbreaker.StateHalfOpened:
+		if b.pending == 0 {
+			break
+		}
+		b.setState(circuitbreaker.StateOpened)
+	}
+	to, listeners := b.state, b.listeners
+	b.mu.Unlock()
+
+	notify(listeners, from, to)
+}
+
+// MarkCanceled frees the slot of a canceled half-open probe for another one.
+func (b *classicBreaker) MarkCanceled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == circuitbreaker.StateHalfOpened && b.pending > 0 {
+		b.pending--
+		b.probes--
+	}
+}
+
+func (b *classicBreaker) shouldTrip() bool {
+	if b.c.ConsecutiveFailures > 0 && b.consecutive >= b.c.ConsecutiveFailures {
+		return true
+	}
+	if b.c.FailureRatio < 0 {
+		return false
+	}
+	success, total := b.summary()
+	return total >= b.c.MinRequests && float64(total-success)/float64(total) >= b.c.FailureRatio
+}
+
+// setState resets the counters of the new state, b.mu must be held.
+func (b *classicBreaker) setState(state int32) {
+	b.state = state
+	b.consecutive = 0
+	b.probes = 0
+	b.pending = 0
+	b.probeOK = 0
+	switch state {
+	case circuitbreaker.StateOpened:
+		b.openedAt = time.Now()
+	case circuitbreaker.StateClosed:
+		b.stat = b.newStat()
+	}
+}
+
+func (b *classicBreaker) Stats() (success, total int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.summary()
+}
+
+func (b *classicBreaker) Subscribe(fn func(from, to int32)) {
+	b.mu.Lock()
+	b.listeners = append(b.listeners[:len(b.listeners):len(b.listeners)], fn)
+	b.mu.Unlock()
+}
+
+func (b *classicBreaker) ForceOpen() {
+	b.force(circuitbreaker.StateOpened, true)
+}
+
+func (b *classicBreaker) ForceClose() {
+	b.force(circuitbreaker.StateClosed, true)
+}
+
+func (b *classicBreaker) Reset() {
+	b.force(circuitbreaker.StateClosed, false)
+}
+
+func (b *classicBreaker) Forced() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.forced
+}
+
+func (b *classicBreaker) force(state int32, forced bool) {
+	b.mu.Lock()
+	from := b.state
+	b.forced = forced
+	b.setState(state)
+	to, listeners := b.state, b.listeners
+	b.mu.Unlock()
+
+	notify(listeners, from, to)
+}
+
+func notify(listeners []func(from, to int32), from, to int32) {
+	if from == to {
+		return
+	}
+	for _, fn := range listeners {
+		fn(from, to)
+	}
+}
+
+func (b *classicBreaker) summary() (success int64, total int64) {
+	b.stat.Reduce(func(iterator metric.BucketIterator) float64 {
+		for iterator.Next() {
+			bucket := iterator.Bucket()
+			total += bucket.Count
+			for _, p := range bucket.Points {
+				success += int64(p)
+			}
+		}
+		return 0
+	})
+	return
+}
@@ -0,0 +1,191 @@
+package classicbreaker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware/circuitbreaker"
+	"github.com/kanengo/ngrpc/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func markSuccess(b circuitbreaker.Breaker, count int) {
+	for i := 0; i < count; i++ {
+		b.MarkSuccess()
+	}
+}
+
+func markFailed(b circuitbreaker.Breaker, count int) {
+	for i := 0; i < count; i++ {
+		b.MarkFailed()
+	}
+}
+
+func state(b circuitbreaker.Breaker) int32 {
+	return b.(circuitbreaker.ManagedBreaker).State()
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	b := New(&Config{ConsecutiveFailures: 3, FailureRatio: -1})
+	markFailed(b, 2)
+	b.MarkSuccess()
+	markFailed(b, 2)
+	assert.Nil(t, b.Allow())
+	b.MarkFailed()
+	assert.Equal(t, ErrOpen, b.Allow())
+	assert.Equal(t, int32(circuitbreaker.StateOpened), state(b))
+}
+
+func TestFailureRatio(t *testing.T) {
+	b := New(&Config{ConsecutiveFailures: -1, FailureRatio: 0.5, MinRequests: 10})
+	for i := 0; i < 4; i++ {
+		b.MarkFailed()
+		b.MarkSuccess()
+	}
+	assert.Nil(t, b.Allow(), "below min requests")
+	b.MarkSuccess()
+	assert.Nil(t, b.Allow())
+	b.MarkFailed()
+	assert.Equal(t, ErrOpen, b.Allow())
+}
+
+func TestHalfOpen(t *testing.T) {
+	var transitions [][2]int32
+	b := New(&Config{
+		ConsecutiveFailures: 1,
+		OpenTimeout:         50 * time.Millisecond,
+		HalfOpenProbes:      2,
+		OnStateChange: func(from, to int32) {
+			transitions = append(transitions, [2]int32{from, to})
+		},
+	})
+	b.MarkFailed()
+	assert.Equal(t, ErrOpen, b.Allow())
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Nil(t, b.Allow())
+	assert.Nil(t, b.Allow())
+	assert.Equal(t, ErrOpen, b.Allow(), "probes are limited")
+	b.MarkSuccess()
+	assert.Equal(t, int32(circuitbreaker.StateHalfOpened), state(b))
+	b.MarkFailed()
+	assert.Equal(t, int32(circuitbreaker.StateOpened), state(b), "a failed probe reopens")
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Nil(t, b.Allow())
+	assert.Nil(t, b.Allow())
+	markSuccess(b, 2)
+	assert.Equal(t, int32(circuitbreaker.StateClosed), state(b))
+	assert.Nil(t, b.Allow())
+
+	opened, closed, half := int32(circuitbreaker.StateOpened), int32(circuitbreaker.StateClosed), int32(circuitbreaker.StateHalfOpened)
+	assert.Equal(t, [][2]int32{
+		{closed, opened}, {opened, half}, {half, opened}, {opened, half}, {half, closed},
+	}, transitions)
+}
+
+type testTransport struct{}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *testTransport) Endpoint() string                { return "discovery:///helloworld" }
+func (tr *testTransport) FullMethod() string              { return "/helloworld.Greeter/SayHello" }
+func (tr *testTransport) RequestHeader() transport.Header { return nil }
+func (tr *testTransport) ReplyHeader() transport.Header   { return nil }
+
+func TestHalfOpenRejected(t *testing.T) {
+	b := New(&Config{ConsecutiveFailures: 1, OpenTimeout: 10 * time.Millisecond})
+	m := circuitbreaker.Client(func() circuitbreaker.Breaker { return b })
+	ctx := transport.NewClientContext(context.Background(), &testTransport{})
+	b.MarkFailed()
+	time.Sleep(20 * time.Millisecond)
+
+	probing, release := make(chan struct{}), make(chan struct{})
+	probe := make(chan error, 1)
+	go func() {
+		_, err := m(func(ctx context.Context, req any) (any, error) {
+			close(probing)
+			<-release
+			return nil, nil
+		})(ctx, nil)
+		probe <- err
+	}()
+	<-probing
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m(func(ctx context.Context, req any) (any, error) {
+				return nil, nil
+			})(ctx, nil)
+			assert.Equal(t, circuitbreaker.ReasonNotAllowed, errors.Reason(err))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(circuitbreaker.StateHalfOpened), state(b), "rejected calls are not probes")
+
+	close(release)
+	assert.Nil(t, <-probe)
+	assert.Equal(t, int32(circuitbreaker.StateClosed), state(b))
+}
+
+func TestHalfOpenUnadmitted(t *testing.T) {
+	b := New(&Config{ConsecutiveFailures: 1, OpenTimeout: 10 * time.Millisecond, HalfOpenProbes: 2})
+	b.MarkFailed()
+	time.Sleep(20 * time.Millisecond)
+	assert.Nil(t, b.Allow())
+	b.MarkSuccess()
+	b.MarkFailed()
+	assert.Equal(t, int32(circuitbreaker.StateHalfOpened), state(b), "only admitted probes count")
+	assert.Nil(t, b.Allow())
+	b.MarkSuccess()
+	assert.Equal(t, int32(circuitbreaker.StateClosed), state(b))
+}
+
+func TestHalfOpenCanceled(t *testing.T) {
+	b := New(&Config{ConsecutiveFailures: 1, OpenTimeout: 10 * time.Millisecond})
+	m := circuitbreaker.Client(func() circuitbreaker.Breaker { return b })
+	ctx := transport.NewClientContext(context.Background(), &testTransport{})
+	b.MarkFailed()
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := m(func(ctx context.Context, req any) (any, error) {
+		return nil, errors.ClientClosed("canceled")
+	})(ctx, nil)
+	assert.True(t, errors.IsClientClosed(err))
+	assert.Equal(t, int32(circuitbreaker.StateHalfOpened), state(b), "a canceled probe does not close")
+
+	_, err = m(func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	})(ctx, nil)
+	assert.Nil(t, err, "the canceled probe frees its slot")
+	assert.Equal(t, int32(circuitbreaker.StateClosed), state(b))
+}
+
+func TestForce(t *testing.T) {
+	b := New(&Config{ConsecutiveFailures: 1})
+	var events int
+	b.Subscribe(func(from, to int32) { events++ })
+
+	b.ForceOpen()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, ErrOpen, b.Allow())
+	assert.True(t, b.Forced())
+
+	b.ForceClose()
+	markFailed(b, 10)
+	assert.Nil(t, b.Allow(), "a forced close ignores failures")
+	success, total := b.Stats()
+	assert.Equal(t, int64(0), success)
+	assert.Equal(t, int64(10), total)
+
+	b.Reset()
+	assert.False(t, b.Forced())
+	b.MarkFailed()
+	assert.Equal(t, ErrOpen, b.Allow())
+	assert.Equal(t, 3, events)
+}
@@ -0,0 +1,112 @@
+package circuitbreaker
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Group holds at most size breakers by key, dropping the least recently used one when full.
+// The managed breakers that are open, half-open or forced are kept, so the group may hold
+// more than size breakers while they are.
+type Group struct {
+	mu    sync.Mutex
+	size  int
+	new   func() Breaker
+	lru   *list.List
+	items map[string]*list.Element
+
+	listeners []func(key string, from, to int32)
+}
+
+type groupEntry struct {
+	key     string
+	breaker Breaker
+}
+
+// NewGroup returns a group creating its breakers with new, size zero means unbounded.
+func NewGroup(size int, new func() Breaker) *Group {
+	return &Group{
+		size:  size,
+		new:   new,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the breaker of key, creating it on the first call.
+func (g *Group) Get(key string) Breaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if e, ok := g.items[key]; ok {
+		g.lru.MoveToFront(e)
+		return e.Value.(*groupEntry).breaker
+	}
+	entry := &groupEntry{key: key, breaker: g.new()}
+	if mb, ok := entry.breaker.(ManagedBreaker); ok {
+		mb.Subscribe(func(from, to int32) { g.notify(key, from, to) })
+	}
+	front := g.lru.PushFront(entry)
+	g.items[key] = front
+	if g.size > 0 {
+		for e := g.lru.Back(); e != front && g.lru.Len() > g.size; {
+			prev := e.Prev()
+			if evictable(e.Value.(*groupEntry).breaker) {
+				g.lru.Remove(e)
+				delete(g.items, e.Value.(*groupEntry).key)
+			}
+			e = prev
+		}
+	}
+	return entry.breaker
+}
+
+// evictable reports whether b can be dropped without losing a state set by its failures or an operator.
+func evictable(b Breaker) bool {
+	mb, ok := b.(ManagedBreaker)
+	if !ok {
+		return true
+	}
+	return !mb.Forced() && mb.State() == StateClosed
+}
+
+// Subscribe registers fn to be called after every state change of the managed breakers of the group.
+func (g *Group) Subscribe(fn func(key string, from, to int32)) {
+	g.mu.Lock()
+	g.listeners = append(g.listeners[:len(g.listeners):len(g.listeners)], fn)
+	g.mu.Unlock()
+}
+
+func (g *Group) notify(key string, from, to int32) {
+	g.mu.Lock()
+	listeners := g.listeners
+	g.mu.Unlock()
+	for _, fn := range listeners {
+		fn(key, from, to)
+	}
+}
+
+// Lookup returns the breaker of key without creating it.
+func (g *Group) Lookup(key string) (Breaker, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if e, ok := g.items[key]; ok {
+		return e.Value.(*groupEntry).breaker, true
+	}
+	return nil, false
+}
+
+// Range calls fn for the breakers of the group until it returns false.
+func (g *Group) Range(fn func(key string, b Breaker) bool) {
+	g.mu.Lock()
+	entries := make([]*groupEntry, 0, g.lru.Len())
+	for e := g.lru.Front(); e != nil; e = e.Next() {
+		entries = append(entries, e.Value.(*groupEntry))
+	}
+	g.mu.Unlock()
+
+	for _, e := range entries {
+		if !fn(e.key, e.breaker) {
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package circuitbreaker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type collector struct {
+	g           *Group
+	state       *prometheus.Desc
+	forced      *prometheus.Desc
+	success     *prometheus.Desc
+	total       *prometheus.Desc
+	transitions *prometheus.CounterVec
+}
+
+// NewCollector returns a prometheus collector exporting the state and the window counts
+// of the managed breakers of g, the state is 0 when closed, 1 when open and 2 when half-open,
+// and counting their state changes by key, from and to state.
+func NewCollector(g *Group, namespace string) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "circuit_breaker", name), help, []string{"key"}, nil)
+	}
+	c := &collector{
+		g:       g,
+		state:   desc("state", "The state of the circuit breaker."),
+		forced:  desc("forced", "Whether the state of the circuit breaker is forced."),
+		success: desc("window_success", "The successful calls in the window of the circuit breaker."),
+		total:   desc("window_total", "The calls in the window of the circuit breaker."),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "circuit_breaker",
+			Name:      "transitions_total",
+			Help:      "The state changes of the circuit breaker.",
+		}, []string{"key", "from", "to"}),
+	}
+	g.Subscribe(func(key string, from, to int32) {
+		c.transitions.WithLabelValues(key, StateString(from), StateString(to)).Inc()
+	})
+	return c
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.state
+	ch <- c.forced
+	ch <- c.success
+	ch <- c.total
+	c.transitions.Describe(ch)
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	c.transitions.Collect(ch)
+	c.g.Range(func(key string, b Breaker) bool {
+		mb, ok := b.(ManagedBreaker)
+		if !ok {
+			return true
+		}
+		success, total := mb.Stats()
+		var forced float64
+		if mb.Forced() {
+			forced = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, float64(mb.State()), key)
+		ch <- prometheus.MustNewConstMetric(c.forced, prometheus.GaugeValue, forced, key)
+		ch <- prometheus.MustNewConstMetric(c.success, prometheus.GaugeValue, float64(success), key)
+		ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(total), key)
+		return true
+	})
+}
@@ -12,6 +12,11 @@ import (
 	"github.com/kanengo/ngrpc/middleware/circuitbreaker"
 )
 
+var (
+	_ circuitbreaker.ManagedBreaker = (*sreBreaker)(nil)
+	_ circuitbreaker.RejectRecorder = (*sreBreaker)(nil)
+)
+
 type sreBreaker struct {
 	stat metric.RollingCounter
 	r    *rand.Rand
@@ -21,7 +26,11 @@ type sreBreaker struct {
 	k       float64
 	request int64
 
-	state int32
+	state  int32
+	forced int32
+
+	lmu       sync.Mutex
+	listeners []func(from, to int32)
 }
 
 type Config struct {
@@ -50,7 +59,7 @@ func (c *Config) fix() {
 	}
 }
 
-func New(c *Config) circuitbreaker.Breaker {
+func New(c *Config) circuitbreaker.ManagedBreaker {
 	if c == nil {
 		c = &Config{}
 	}
@@ -70,17 +79,24 @@ func New(c *Config) circuitbreaker.Breaker {
 }
 
 func (b *sreBreaker) Allow() error {
+	if atomic.LoadInt32(&b.forced) == 1 {
+		if atomic.LoadInt32(&b.state) == circuitbreaker.StateOpened {
+			return errors.ServiceUnavailable("service unavailable")
+		}
+		return nil
+	}
+
 	success, total := b.summary()
 	k := b.k * float64(success)
 	if total < b.request || float64(total) < k {
 		if atomic.LoadInt32(&b.state) == circuitbreaker.StateOpened {
-			atomic.CompareAndSwapInt32(&b.state, circuitbreaker.StateOpened, circuitbreaker.StateClosed)
+			b.transit(circuitbreaker.StateOpened, circuitbreaker.StateClosed)
 		}
 		return nil
 	}
 
 	if atomic.LoadInt32(&b.state) == circuitbreaker.StateClosed {
-		atomic.CompareAndSwapInt32(&b.state, circuitbreaker.StateClosed, circuitbreaker.StateOpened)
+		b.transit(circuitbreaker.StateClosed, circuitbreaker.StateOpened)
 	}
 
 	dr := math.Max(0, (float64(total)-k)/float64(total+1))
@@ -100,6 +116,69 @@ func (b *sreBreaker) MarkFailed() {
 	b.stat.Add(0)
 }
 
+// MarkRejected counts a throttled call as a failed request of the window.
+func (b *sreBreaker) MarkRejected() {
+	b.stat.Add(0)
+}
+
+func (b *sreBreaker) State() int32 {
+	return atomic.LoadInt32(&b.state)
+}
+
+func (b *sreBreaker) Stats() (success, total int64) {
+	return b.summary()
+}
+
+func (b *sreBreaker) Subscribe(fn func(from, to int32)) {
+	b.lmu.Lock()
+	b.listeners = append(b.listeners, fn)
+	b.lmu.Unlock()
+}
+
+func (b *sreBreaker) ForceOpen() {
+	atomic.StoreInt32(&b.forced, 1)
+	b.store(circuitbreaker.StateOpened)
+}
+
+func (b *sreBreaker) ForceClose() {
+	atomic.StoreInt32(&b.forced, 1)
+	b.store(circuitbreaker.StateClosed)
+}
+
+// Reset resumes the adaptive throttling, the breaker closes until the window says otherwise.
+func (b *sreBreaker) Reset() {
+	atomic.StoreInt32(&b.forced, 0)
+	b.store(circuitbreaker.StateClosed)
+}
+
+func (b *sreBreaker) Forced() bool {
+	return atomic.LoadInt32(&b.forced) == 1
+}
+
+// transit moves the state from from to to and notifies the listeners when it changed.
+func (b *sreBreaker) transit(from, to int32) {
+	if from == to || !atomic.CompareAndSwapInt32(&b.state, from, to) {
+		return
+	}
+	b.notify(from, to)
+}
+
+// store sets the state whatever it was, unlike transit it never loses to a concurrent transition.
+func (b *sreBreaker) store(to int32) {
+	if from := atomic.SwapInt32(&b.state, to); from != to {
+		b.notify(from, to)
+	}
+}
+
+func (b *sreBreaker) notify(from, to int32) {
+	b.lmu.Lock()
+	listeners := b.listeners
+	b.lmu.Unlock()
+	for _, fn := range listeners {
+		fn(from, to)
+	}
+}
+
 func (b *sreBreaker) summary() (success int64, total int64) {
 	b.stat.Reduce(func(iterator metric.BucketIterator) float64 {
 		for iterator.Next() {
@@ -3,6 +3,7 @@ package srebreaker
 import (
 	"math"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
@@ -182,3 +183,64 @@ func BenchmarkSreBreakerAllow(b *testing.B) {
 		}
 	}
 }
+
+func TestSREControl(t *testing.T) {
+	b := New(nil)
+	var transitions [][2]int32
+	b.Subscribe(func(from, to int32) {
+		transitions = append(transitions, [2]int32{from, to})
+	})
+
+	b.ForceOpen()
+	assert.True(t, b.Forced())
+	assert.Equal(t, int32(circuitbreaker.StateOpened), b.State())
+	assert.NotEqual(t, b.Allow(), nil)
+
+	b.ForceClose()
+	markFailed(b, 10000)
+	assert.Equal(t, b.Allow(), nil)
+
+	b.Reset()
+	assert.False(t, b.Forced())
+	assert.NotEqual(t, b.Allow(), nil)
+	success, total := b.Stats()
+	assert.Equal(t, int64(0), success)
+	assert.Equal(t, int64(10000), total)
+
+	opened, closed := int32(circuitbreaker.StateOpened), int32(circuitbreaker.StateClosed)
+	assert.Equal(t, [][2]int32{{closed, opened}, {opened, closed}, {closed, opened}}, transitions)
+}
+
+func TestSREForceConcurrent(t *testing.T) {
+	b := New(nil)
+	var (
+		mu      sync.Mutex
+		changes int
+	)
+	b.Subscribe(func(from, to int32) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.NotEqual(t, from, to)
+		changes++
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				b.ForceOpen()
+			} else {
+				b.ForceClose()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	b.ForceOpen()
+	assert.Equal(t, int32(circuitbreaker.StateOpened), b.State())
+	b.ForceClose()
+	assert.Equal(t, int32(circuitbreaker.StateClosed), b.State())
+	assert.NotZero(t, changes)
+}
@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/kanengo/goutil/pkg/log"
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/transport"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type Option func(*options)
+
+type options struct {
+	sampleRate float64
+	redact     map[string]bool
+	info       func(msg string, fields ...zap.Field)
+	error      func(msg string, fields ...zap.Field)
+}
+
+// WithLogger with the logger of the calls, defaults to the global logger.
+func WithLogger(l *zap.Logger) Option {
+	return func(o *options) {
+		o.info = l.Info
+		o.error = l.Error
+	}
+}
+
+// WithSampleRate with the fraction of the successful calls logged, the failed calls are always logged.
+func WithSampleRate(rate float64) Option {
+	return func(o *options) {
+		o.sampleRate = rate
+	}
+}
+
+// WithRedact with the dotted proto field paths redacted from the logged requests, e.g. "user.password",
+// in addition to the fields marked with the (ngrpc.logging.sensitive) option.
+func WithRedact(paths ...string) Option {
+	return func(o *options) {
+		for _, p := range paths {
+			o.redact[p] = true
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		sampleRate: 1,
+		redact:     make(map[string]bool),
+		info:       log.Info,
+		error:      log.Error,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Server logs the calls handled by the server.
+func Server(opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				o.log(ctx, "server", tr, req, reply, err, time.Since(start))
+			}
+			return reply, err
+		}
+	}
+}
+
+// Client logs the calls made by the client.
+func Client(opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			start := time.Now()
+			reply, err := handler(ctx, req)
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				o.log(ctx, "client", tr, req, reply, err, time.Since(start))
+			}
+			return reply, err
+		}
+	}
+}
+
+func (o *options) log(ctx context.Context, kind string, tr transport.Transporter, req, reply any, err error, latency time.Duration) {
+	if err == nil && o.sampleRate < 1 && rand.Float64() >= o.sampleRate {
+		return
+	}
+	fields := []zap.Field{
+		zap.String("kind", kind),
+		zap.String("component", tr.Kind().String()),
+		zap.String("method", tr.FullMethod()),
+		zap.String("endpoint", tr.Endpoint()),
+		zap.Duration("latency", latency),
+		zap.Int32("code", errors.Code(err)),
+		zap.String("reason", errors.Reason(err)),
+		zap.Int("request_size", size(req)),
+		zap.Int("reply_size", size(reply)),
+		zap.String("args", o.format(req)),
+	}
+	if kind == "server" {
+		fields = append(fields, zap.String("peer", peerAddr(ctx, tr)))
+	}
+	if err != nil {
+		o.error("[logging] "+kind, append(fields, zap.Error(err))...)
+		return
+	}
+	o.info("[logging] "+kind, fields...)
+}
+
+// format renders req with its sensitive fields redacted, only the type of the other values
+// is logged as they may be anything, such as the stream of a streaming call.
+func (o *options) format(req any) string {
+	m, ok := req.(proto.Message)
+	if !ok {
+		if req == nil {
+			return ""
+		}
+		return fmt.Sprintf("%T", req)
+	}
+	data, err := protojson.Marshal(redact(m, o.redact))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func size(v any) int {
+	if m, ok := v.(proto.Message); ok {
+		return proto.Size(m)
+	}
+	return 0
+}
+
+func peerAddr(ctx context.Context, tr transport.Transporter) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	if r, ok := tr.(interface{ Request() *http.Request }); ok && r.Request() != nil {
+		return r.Request().RemoteAddr
+	}
+	return ""
+}
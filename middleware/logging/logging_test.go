@@ -0,0 +1,192 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/transport"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// loginRequest builds a message type with a password field marked sensitive.
+func loginRequest(t *testing.T) protoreflect.MessageType {
+	sensitive := &descriptorpb.FieldOptions{}
+	proto.SetExtension(sensitive, E_Sensitive, true)
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("login.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("LoginRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("user"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), JsonName: proto.String("user")},
+				{Name: proto.String("password"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), JsonName: proto.String("password"), Options: sensitive},
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dynamicpb.NewMessageType(fd.Messages().Get(0))
+}
+
+func TestRedact(t *testing.T) {
+	mt := loginRequest(t)
+	msg := mt.New()
+	msg.Set(mt.Descriptor().Fields().ByName("user"), protoreflect.ValueOfString("kratos"))
+	msg.Set(mt.Descriptor().Fields().ByName("password"), protoreflect.ValueOfString("secret"))
+
+	got := redact(msg.Interface(), nil).ProtoReflect()
+	if v := got.Get(mt.Descriptor().Fields().ByName("password")).String(); v != redacted {
+		t.Errorf("expect %s, got %s", redacted, v)
+	}
+	if v := msg.Get(mt.Descriptor().Fields().ByName("password")).String(); v != "secret" {
+		t.Errorf("expect the original message unchanged, got %s", v)
+	}
+
+	st := &errors.Status{Code: 400, Message: "token abc", Metadata: map[string]string{"k": "v"}, Reason: "R"}
+	rs := redact(st, map[string]bool{"message": true, "metadata": true}).(*errors.Status)
+	want := &errors.Status{Code: 400, Message: redacted, Reason: "R"}
+	if !proto.Equal(rs, want) {
+		t.Errorf("expect %v, got %v", want, rs)
+	}
+}
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string { return hc[key] }
+
+func (hc headerCarrier) Set(key, value string) { hc[key] = value }
+
+func (hc headerCarrier) Keys() []string { return nil }
+
+type testTransport struct{}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *testTransport) Endpoint() string                { return "grpc://127.0.0.1:9000" }
+func (tr *testTransport) FullMethod() string              { return "/helloworld.Greeter/SayHello" }
+func (tr *testTransport) RequestHeader() transport.Header { return headerCarrier{} }
+func (tr *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func TestLogging(t *testing.T) {
+	mt := loginRequest(t)
+	req := mt.New()
+	req.Set(mt.Descriptor().Fields().ByName("user"), protoreflect.ValueOfString("kratos"))
+	req.Set(mt.Descriptor().Fields().ByName("password"), protoreflect.ValueOfString("secret"))
+	failed := func(ctx context.Context, req any) (any, error) {
+		return nil, errors.BadRequest("bad").WithReason("BAD_NAME")
+	}
+	serverCtx := transport.NewServerContext(context.Background(), &testTransport{})
+	clientCtx := transport.NewClientContext(context.Background(), &testTransport{})
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := WithLogger(zap.New(core))
+	if _, err := Server(logger)(echo)(serverCtx, req.Interface()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Client(logger)(failed)(clientCtx, req.Interface()); !errors.IsBadRequest(err) {
+		t.Fatalf("expect bad request, got %v", err)
+	}
+	// a client context alone is not logged by the server middleware
+	_, _ = Server(logger)(echo)(clientCtx, req.Interface())
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("expect %d entries, got %d", 2, len(entries))
+	}
+	tests := []struct {
+		level  zapcore.Level
+		msg    string
+		fields map[string]any
+	}{
+		{zapcore.InfoLevel, "[logging] server", map[string]any{
+			"kind":      "server",
+			"component": "grpc",
+			"method":    "/helloworld.Greeter/SayHello",
+			"endpoint":  "grpc://127.0.0.1:9000",
+			"code":      int32(200),
+			"reason":    "",
+			"peer":      "",
+		}},
+		{zapcore.ErrorLevel, "[logging] client", map[string]any{
+			"kind":   "client",
+			"code":   int32(400),
+			"reason": "BAD_NAME",
+			"error":  errors.BadRequest("bad").WithReason("BAD_NAME").Error(),
+		}},
+	}
+	for i, test := range tests {
+		e := entries[i]
+		if e.Level != test.level || e.Message != test.msg {
+			t.Errorf("expect %s %s, got %s %s", test.level, test.msg, e.Level, e.Message)
+		}
+		fields := e.ContextMap()
+		for k, want := range test.fields {
+			if got := fields[k]; !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: expect %s=%v, got %v", test.msg, k, want, got)
+			}
+		}
+	}
+	for _, e := range entries {
+		var args map[string]string
+		if err := json.Unmarshal([]byte(e.ContextMap()["args"].(string)), &args); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]string{"user": "kratos", "password": redacted}; !reflect.DeepEqual(args, want) {
+			t.Errorf("expect args %v, got %v", want, args)
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	ctx := transport.NewServerContext(context.Background(), &testTransport{})
+	core, logs := observer.New(zapcore.InfoLevel)
+	h := Server(WithLogger(zap.New(core)), WithSampleRate(0))
+	for i := 0; i < 10; i++ {
+		_, _ = h(echo)(ctx, "ok")
+	}
+	if n := logs.Len(); n != 0 {
+		t.Errorf("expect the successful calls sampled out, got %d entries", n)
+	}
+	_, _ = h(func(ctx context.Context, req any) (any, error) {
+		return nil, errors.InternalServer("boom")
+	})(ctx, "ok")
+	if n := logs.FilterLevelExact(zapcore.ErrorLevel).Len(); n != 1 {
+		t.Errorf("expect the failed call logged, got %d entries", n)
+	}
+
+	core, logs = observer.New(zapcore.InfoLevel)
+	h = Server(WithLogger(zap.New(core)), WithSampleRate(0.5))
+	for i := 0; i < 1000; i++ {
+		_, _ = h(echo)(ctx, "ok")
+	}
+	if n := logs.Len(); n < 350 || n > 650 {
+		t.Errorf("expect about half of the calls logged, got %d", n)
+	}
+}
+
+func echo(ctx context.Context, req any) (any, error) {
+	return req, nil
+}
+
+func TestFormat(t *testing.T) {
+	o := newOptions(nil)
+	type stream struct{ secret string }
+	if got, want := o.format(&stream{secret: "password"}), "*logging.stream"; got != want {
+		t.Errorf("expect %s, got %s", want, got)
+	}
+	if got := o.format(nil); got != "" {
+		t.Errorf("expect empty args, got %s", got)
+	}
+}
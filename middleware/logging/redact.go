@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const redacted = "******"
+
+// redact returns a copy of m with the sensitive fields and the fields in paths redacted,
+// strings are replaced with a mask and the other values are cleared.
+func redact(m proto.Message, paths map[string]bool) proto.Message {
+	if m == nil {
+		return m
+	}
+	m = proto.Clone(m)
+	redactMessage(m.ProtoReflect(), "", paths)
+	return m
+}
+
+func redactMessage(msg protoreflect.Message, prefix string, paths map[string]bool) {
+	var masked []protoreflect.FieldDescriptor
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		path := prefix + string(fd.Name())
+		if paths[path] || isSensitive(fd) {
+			masked = append(masked, fd)
+			return true
+		}
+		if fd.Kind() != protoreflect.MessageKind && fd.Kind() != protoreflect.GroupKind {
+			return true
+		}
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				redactMessage(list.Get(i).Message(), path+".", paths)
+			}
+		case fd.IsMap():
+			if fd.MapValue().Kind() == protoreflect.MessageKind {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					redactMessage(mv.Message(), path+".", paths)
+					return true
+				})
+			}
+		default:
+			redactMessage(v.Message(), path+".", paths)
+		}
+		return true
+	})
+
+	for _, fd := range masked {
+		if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+			msg.Set(fd, protoreflect.ValueOfString(redacted))
+		} else {
+			msg.Clear(fd)
+		}
+	}
+}
+
+func isSensitive(fd protoreflect.FieldDescriptor) bool {
+	opts := fd.Options()
+	if opts == nil {
+		return false
+	}
+	sensitive, _ := proto.GetExtension(opts, E_Sensitive).(bool)
+	return sensitive
+}
@@ -0,0 +1,112 @@
+package metadata
+
+import (
+	"context"
+	"strings"
+
+	"github.com/kanengo/ngrpc/metadata"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/transport"
+)
+
+type Option func(*options)
+
+type options struct {
+	globalPrefix []string
+	localPrefix  []string
+	md           metadata.Metadata
+}
+
+// WithGlobalPrefix with the prefixes of the keys forwarded across all hops, defaults to "x-md-global-".
+func WithGlobalPrefix(prefix ...string) Option {
+	return func(o *options) {
+		o.globalPrefix = prefix
+	}
+}
+
+// WithLocalPrefix with the prefixes of the keys received from the caller only, defaults to "x-md-local-".
+func WithLocalPrefix(prefix ...string) Option {
+	return func(o *options) {
+		o.localPrefix = prefix
+	}
+}
+
+// WithConstants with metadata sent on every outgoing call.
+func WithConstants(md metadata.Metadata) Option {
+	return func(o *options) {
+		o.md = md
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		globalPrefix: []string{"x-md-global-"},
+		localPrefix:  []string{"x-md-local-"},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func hasPrefix(key string, prefix []string) bool {
+	for _, p := range prefix {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Server extracts the request headers with the global or local prefixes into the server metadata.
+func Server(opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			md := metadata.New()
+			header := tr.RequestHeader()
+			for _, k := range header.Keys() {
+				key := strings.ToLower(k)
+				if hasPrefix(key, o.globalPrefix) || hasPrefix(key, o.localPrefix) {
+					md.Set(key, header.Get(k))
+				}
+			}
+			return handler(metadata.NewServerContext(ctx, md), req)
+		}
+	}
+}
+
+// Client sets the constants, the client metadata and the global keys of the server metadata
+// onto the outgoing request headers.
+func Client(opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			header := tr.RequestHeader()
+			for k, v := range o.md {
+				header.Set(k, v)
+			}
+			if md, ok := metadata.FromServerContext(ctx); ok {
+				for k, v := range md {
+					if hasPrefix(k, o.globalPrefix) {
+						header.Set(k, v)
+					}
+				}
+			}
+			if md, ok := metadata.FromClientContext(ctx); ok {
+				for k, v := range md {
+					header.Set(k, v)
+				}
+			}
+			return handler(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package metadata
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kanengo/ngrpc/metadata"
+	"github.com/kanengo/ngrpc/transport"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string { return hc[key] }
+
+func (hc headerCarrier) Set(key, value string) { hc[key] = value }
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type testTransport struct{ header headerCarrier }
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) FullMethod() string              { return "" }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+func (tr *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func TestPropagation(t *testing.T) {
+	serverTr := &testTransport{header: headerCarrier{
+		"X-Md-Global-Tenant": "t1",
+		"X-Md-Local-User":    "u1",
+		"Authorization":      "token",
+	}}
+	clientTr := &testTransport{header: headerCarrier{}}
+
+	var serverMD metadata.Metadata
+	client := Client(WithConstants(metadata.Metadata{"x-md-local-caller": "svc-a"}))(func(ctx context.Context, req any) (any, error) {
+		return req, nil
+	})
+	// constants only apply to outgoing calls
+	h := Server(WithConstants(metadata.Metadata{"x-md-global-app": "a"}))(func(ctx context.Context, req any) (any, error) {
+		serverMD, _ = metadata.FromServerContext(ctx)
+		ctx = metadata.AppendToClientContext(ctx, "x-md-local-trace", "abc")
+		return client(transport.NewClientContext(ctx, clientTr), req)
+	})
+	if _, err := h(transport.NewServerContext(context.Background(), serverTr), "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := metadata.Metadata{"x-md-global-tenant": "t1", "x-md-local-user": "u1"}
+	if !reflect.DeepEqual(serverMD, want) {
+		t.Errorf("expect %v, got %v", want, serverMD)
+	}
+	wantHeader := headerCarrier{
+		"x-md-global-tenant": "t1",
+		"x-md-local-caller":  "svc-a",
+		"x-md-local-trace":   "abc",
+	}
+	if !reflect.DeepEqual(clientTr.header, wantHeader) {
+		t.Errorf("expect %v, got %v", wantHeader, clientTr.header)
+	}
+}
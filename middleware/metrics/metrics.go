@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/transport"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type Option func(*options)
+
+type options struct {
+	namespace  string
+	registerer prometheus.Registerer
+	buckets    []float64
+}
+
+// WithNamespace with the namespace of the metric names, defaults to "ngrpc".
+func WithNamespace(ns string) Option {
+	return func(o *options) {
+		o.namespace = ns
+	}
+}
+
+// WithRegisterer with the registerer of the collectors, defaults to prometheus.DefaultRegisterer.
+func WithRegisterer(r prometheus.Registerer) Option {
+	return func(o *options) {
+		o.registerer = r
+	}
+}
+
+// WithBuckets with the buckets of the latency histogram in seconds.
+func WithBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		namespace:  "ngrpc",
+		registerer: prometheus.DefaultRegisterer,
+		buckets:    []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+type collectors struct {
+	requests *prometheus.CounterVec
+	inflight *prometheus.GaugeVec
+	seconds  *prometheus.HistogramVec
+}
+
+// newCollectors registers the collectors, the server and client middleware share
+// the collectors already registered with the same registerer and namespace.
+func newCollectors(o *options) *collectors {
+	c := &collectors{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "requests_total",
+			Help:      "The total number of processed requests.",
+		}, []string{"side", "kind", "method", "code"}),
+		inflight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: o.namespace,
+			Name:      "requests_in_flight",
+			Help:      "The number of requests being processed.",
+		}, []string{"side", "kind", "method"}),
+		seconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace,
+			Name:      "request_duration_seconds",
+			Help:      "The latency of the requests in seconds.",
+			Buckets:   o.buckets,
+		}, []string{"side", "kind", "method"}),
+	}
+	c.requests = register(o.registerer, c.requests)
+	c.inflight = register(o.registerer, c.inflight)
+	c.seconds = register(o.registerer, c.seconds)
+	return c
+}
+
+func register[T prometheus.Collector](r prometheus.Registerer, c T) T {
+	if err := r.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if stderrors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+// Server records the requests handled by the server.
+func Server(opts ...Option) middleware.Middleware {
+	c := newCollectors(newOptions(opts))
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			return c.observe("server", tr, func() (any, error) { return handler(ctx, req) })
+		}
+	}
+}
+
+// Client records the requests sent by the client.
+func Client(opts ...Option) middleware.Middleware {
+	c := newCollectors(newOptions(opts))
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			return c.observe("client", tr, func() (any, error) { return handler(ctx, req) })
+		}
+	}
+}
+
+func (c *collectors) observe(side string, tr transport.Transporter, call func() (any, error)) (any, error) {
+	kind, method := tr.Kind().String(), tr.FullMethod()
+	inflight := c.inflight.WithLabelValues(side, kind, method)
+	inflight.Inc()
+	defer inflight.Dec()
+	start := time.Now()
+
+	reply, err := call()
+
+	c.seconds.WithLabelValues(side, kind, method).Observe(time.Since(start).Seconds())
+	c.requests.WithLabelValues(side, kind, method, strconv.Itoa(int(errors.Code(err)))).Inc()
+	return reply, err
+}
+
+// Handler returns the handler exposing the metrics gathered by the registerer of the options,
+// e.g. httpServer.Handle("/metrics", metrics.Handler()).
+func Handler(opts ...Option) http.Handler {
+	o := newOptions(opts)
+	if g, ok := o.registerer.(prometheus.Gatherer); ok {
+		return promhttp.InstrumentMetricHandler(o.registerer, promhttp.HandlerFor(g, promhttp.HandlerOpts{}))
+	}
+	return promhttp.Handler()
+}
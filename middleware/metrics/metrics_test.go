@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/transport"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string { return hc[key] }
+
+func (hc headerCarrier) Set(key, value string) { hc[key] = value }
+
+func (hc headerCarrier) Keys() []string { return nil }
+
+type testTransport struct{}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) FullMethod() string              { return "/helloworld.Greeter/SayHello" }
+func (tr *testTransport) RequestHeader() transport.Header { return headerCarrier{} }
+func (tr *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	server := Server(WithRegisterer(reg))(func(ctx context.Context, req any) (any, error) {
+		if req == "error" {
+			return nil, errors.NotFound("not found")
+		}
+		return req, nil
+	})
+	client := Client(WithRegisterer(reg))(func(ctx context.Context, req any) (any, error) {
+		return req, nil
+	})
+
+	sctx := transport.NewServerContext(context.Background(), &testTransport{})
+	_, _ = server(sctx, "ok")
+	_, _ = server(sctx, "ok")
+	_, _ = server(sctx, "error")
+	_, _ = client(transport.NewClientContext(context.Background(), &testTransport{}), "ok")
+
+	c := newCollectors(&options{namespace: "ngrpc", registerer: reg, buckets: prometheus.DefBuckets})
+	tests := []struct {
+		labels []string
+		want   float64
+	}{
+		{[]string{"server", "grpc", "/helloworld.Greeter/SayHello", "200"}, 2},
+		{[]string{"server", "grpc", "/helloworld.Greeter/SayHello", "404"}, 1},
+		{[]string{"client", "grpc", "/helloworld.Greeter/SayHello", "200"}, 1},
+	}
+	for _, test := range tests {
+		if got := testutil.ToFloat64(c.requests.WithLabelValues(test.labels...)); got != test.want {
+			t.Errorf("%v: expect %v, got %v", test.labels, test.want, got)
+		}
+	}
+	if got := testutil.ToFloat64(c.inflight.WithLabelValues("server", "grpc", "/helloworld.Greeter/SayHello")); got != 0 {
+		t.Errorf("expect %v, got %v", 0, got)
+	}
+
+	w := httptest.NewRecorder()
+	Handler(WithRegisterer(reg)).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if body := w.Body.String(); !strings.Contains(body, "ngrpc_request_duration_seconds_count") {
+		t.Errorf("expect the histogram exposed, got %s", body)
+	}
+}
+
+func TestMetricsPanic(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	server := Server(WithRegisterer(reg))(func(ctx context.Context, req any) (any, error) {
+		panic("boom")
+	})
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = server(transport.NewServerContext(context.Background(), &testTransport{}), "ok")
+	}()
+
+	c := newCollectors(&options{namespace: "ngrpc", registerer: reg, buckets: prometheus.DefBuckets})
+	if got := testutil.ToFloat64(c.inflight.WithLabelValues("server", "grpc", "/helloworld.Greeter/SayHello")); got != 0 {
+		t.Errorf("expect %v, got %v", 0, got)
+	}
+}
@@ -0,0 +1,234 @@
+package bbr
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/kanengo/goutil/pkg/metric"
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
+)
+
+var (
+	_ ratelimit.FeedbackLimiter = (*BBR)(nil)
+)
+
+// Stat holds the inputs of the last decision of the limiter.
+type Stat struct {
+	// CPU is the smoothed cpu usage in permille.
+	CPU int64
+	// InFlight is the number of requests being handled.
+	InFlight int64
+	// MaxInFlight is the estimated capacity, max pass rate times min RT.
+	MaxInFlight int64
+	// MinRT is the lowest average response time of the window buckets in milliseconds.
+	MinRT int64
+	// MaxPass is the highest number of requests passed by a window bucket.
+	MaxPass int64
+}
+
+type Option func(*options)
+
+type options struct {
+	window       time.Duration
+	bucket       int
+	cpuThreshold int64
+	cpu          func() int64
+}
+
+// WithWindow with the duration of the statistics window, defaults to 10s.
+func WithWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.window = d
+	}
+}
+
+// WithBucket with the number of buckets of the window, defaults to 100.
+func WithBucket(b int) Option {
+	return func(o *options) {
+		o.bucket = b
+	}
+}
+
+// WithCPUThreshold with the cpu usage in permille above which requests are dropped, defaults to 800.
+func WithCPUThreshold(threshold int64) Option {
+	return func(o *options) {
+		o.cpuThreshold = threshold
+	}
+}
+
+// WithCPU with the source of the cpu usage in permille, defaults to the cgroup or /proc sampler.
+func WithCPU(cpu func() int64) Option {
+	return func(o *options) {
+		o.cpu = cpu
+	}
+}
+
+type cache struct {
+	val  int64
+	time int64
+}
+
+// BBR drops requests while the cpu usage is above the threshold and the requests in flight
+// exceed the capacity estimated from the max pass rate and the min response time of the window.
+type BBR struct {
+	opts            options
+	passStat        metric.RollingCounter
+	rtStat          metric.RollingCounter
+	inFlight        int64
+	bucketDuration  time.Duration
+	bucketPerSecond int64
+	prevDropTime    int64
+	maxPassCache    atomic.Value
+	minRTCache      atomic.Value
+}
+
+func NewLimiter(opts ...Option) *BBR {
+	o := options{
+		window:       10 * time.Second,
+		bucket:       100,
+		cpuThreshold: 800,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.cpu == nil {
+		startCPUSampler()
+		o.cpu = loadCPU
+	}
+
+	bucketDuration := o.window / time.Duration(o.bucket)
+	counterOpts := metric.RollingCounterOpts{
+		Size:           o.bucket,
+		BucketDuration: bucketDuration,
+	}
+	return &BBR{
+		opts:            o,
+		passStat:        metric.NewRollingCounter(counterOpts),
+		rtStat:          metric.NewRollingCounter(counterOpts),
+		bucketDuration:  bucketDuration,
+		bucketPerSecond: int64(time.Second / bucketDuration),
+	}
+}
+
+// Allow only checks the current load, use Acquire to have the request measured.
+func (l *BBR) Allow() error {
+	if l.shouldDrop() {
+		return ratelimit.ErrTriggerLimit
+	}
+	return nil
+}
+
+func (l *BBR) Acquire() (ratelimit.DoneFunc, error) {
+	if l.shouldDrop() {
+		return nil, ratelimit.ErrTriggerLimit
+	}
+	atomic.AddInt64(&l.inFlight, 1)
+	start := time.Now()
+	return func(error) {
+		rt := int64(math.Ceil(float64(time.Since(start)) / float64(time.Millisecond)))
+		l.rtStat.Add(rt)
+		atomic.AddInt64(&l.inFlight, -1)
+		l.passStat.Add(1)
+	}, nil
+}
+
+// Stat returns the current inputs of the decision.
+func (l *BBR) Stat() Stat {
+	return Stat{
+		CPU:         l.opts.cpu(),
+		InFlight:    atomic.LoadInt64(&l.inFlight),
+		MaxInFlight: l.maxInFlight(),
+		MinRT:       l.minRT(),
+		MaxPass:     l.maxPass(),
+	}
+}
+
+func (l *BBR) shouldDrop() bool {
+	now := time.Now().UnixNano()
+	if l.opts.cpu() < l.opts.cpuThreshold {
+		prevDrop := atomic.LoadInt64(&l.prevDropTime)
+		if prevDrop == 0 {
+			return false
+		}
+		// keep dropping for a second after the cpu went below the threshold
+		if time.Duration(now-prevDrop) <= time.Second {
+			return atomic.LoadInt64(&l.inFlight) > l.maxInFlight()
+		}
+		atomic.CompareAndSwapInt64(&l.prevDropTime, prevDrop, 0)
+		return false
+	}
+
+	drop := atomic.LoadInt64(&l.inFlight) > l.maxInFlight()
+	if drop && atomic.LoadInt64(&l.prevDropTime) == 0 {
+		atomic.StoreInt64(&l.prevDropTime, now)
+	}
+	return drop
+}
+
+func (l *BBR) maxInFlight() int64 {
+	return int64(math.Floor(float64(l.maxPass()*l.minRT()*l.bucketPerSecond)/1000.0 + 0.5))
+}
+
+// bucketIndex identifies the current bucket, the cached values are recomputed once per bucket.
+func (l *BBR) bucketIndex() int64 {
+	return time.Now().UnixNano() / int64(l.bucketDuration)
+}
+
+func (l *BBR) maxPass() int64 {
+	if c, ok := l.maxPassCache.Load().(*cache); ok && c.time == l.bucketIndex() {
+		return c.val
+	}
+	var maxPass int64 = 1
+	for _, b := range completedBuckets(l.passStat) {
+		if pass := int64(sum(b.Points)); pass > maxPass {
+			maxPass = pass
+		}
+	}
+	l.maxPassCache.Store(&cache{val: maxPass, time: l.bucketIndex()})
+	return maxPass
+}
+
+func (l *BBR) minRT() int64 {
+	if c, ok := l.minRTCache.Load().(*cache); ok && c.time == l.bucketIndex() {
+		return c.val
+	}
+	minRT := math.MaxFloat64
+	for _, b := range completedBuckets(l.rtStat) {
+		if b.Count == 0 {
+			continue
+		}
+		if avg := math.Ceil(sum(b.Points) / float64(b.Count)); avg < minRT {
+			minRT = avg
+		}
+	}
+	rt := int64(minRT)
+	if minRT == math.MaxFloat64 || rt <= 0 {
+		rt = 1
+	}
+	l.minRTCache.Store(&cache{val: rt, time: l.bucketIndex()})
+	return rt
+}
+
+// completedBuckets returns the buckets of the window but the current one, which is still filling.
+func completedBuckets(c metric.RollingCounter) []metric.Bucket {
+	var buckets []metric.Bucket
+	c.Reduce(func(iterator metric.BucketIterator) float64 {
+		for iterator.Next() {
+			buckets = append(buckets, iterator.Bucket())
+		}
+		return 0
+	})
+	if len(buckets) > 0 {
+		buckets = buckets[:len(buckets)-1]
+	}
+	return buckets
+}
+
+func sum(points []float64) float64 {
+	var s float64
+	for _, p := range points {
+		s += p
+	}
+	return s
+}
@@ -0,0 +1,75 @@
+package bbr
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
+)
+
+func TestBBRLowCPU(t *testing.T) {
+	l := NewLimiter(WithCPU(func() int64 { return 100 }))
+	var dones []ratelimit.DoneFunc
+	for i := 0; i < 100; i++ {
+		done, err := l.Acquire()
+		if err != nil {
+			t.Fatalf("expected admitted under low cpu, got %v", err)
+		}
+		dones = append(dones, done)
+	}
+	if got := l.Stat().InFlight; got != 100 {
+		t.Fatalf("expected 100 in flight, got %d", got)
+	}
+	for _, done := range dones {
+		done(nil)
+	}
+	if got := l.Stat().InFlight; got != 0 {
+		t.Fatalf("expected 0 in flight, got %d", got)
+	}
+}
+
+func TestBBRHighCPU(t *testing.T) {
+	var cpu int64 = 100
+	l := NewLimiter(
+		WithWindow(time.Second),
+		WithBucket(10),
+		WithCPU(func() int64 { return atomic.LoadInt64(&cpu) }),
+	)
+	// fill a few buckets with 10 requests of ~10ms each
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 10; j++ {
+			done, err := l.Acquire()
+			if err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(10 * time.Millisecond)
+			done(nil)
+		}
+	}
+
+	atomic.StoreInt64(&cpu, 900)
+	stat := l.Stat()
+	if stat.MaxPass <= 1 || stat.MinRT < 10 {
+		t.Fatalf("unexpected stat %+v", stat)
+	}
+	var admitted int64
+	for i := 0; i < 1000; i++ {
+		if _, err := l.Acquire(); err == nil {
+			admitted++
+		}
+	}
+	stat = l.Stat()
+	if admitted == 1000 || stat.InFlight != admitted || admitted > stat.MaxInFlight+1 {
+		t.Fatalf("expected in flight capped by %d, admitted %d", stat.MaxInFlight, admitted)
+	}
+	if err := l.Allow(); err != ratelimit.ErrTriggerLimit {
+		t.Fatalf("expected %v, got %v", ratelimit.ErrTriggerLimit, err)
+	}
+
+	// drops go on for a second after the cpu cools down
+	atomic.StoreInt64(&cpu, 100)
+	if err := l.Allow(); err == nil {
+		t.Fatal("expected drop during cooldown")
+	}
+}
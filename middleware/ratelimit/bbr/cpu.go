@@ -0,0 +1,46 @@
+package bbr
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cpuUsage is the smoothed cpu usage of the process in permille, updated by the sampler.
+var (
+	cpuUsage    int64
+	cpuOnce     sync.Once
+	cpuInterval = 500 * time.Millisecond
+	cpuDecay    = 0.95
+)
+
+// cpuReader returns the cpu usage in permille since its previous call.
+type cpuReader interface {
+	Usage() (int64, error)
+}
+
+// startCPUSampler starts the goroutine updating cpuUsage with an exponential moving average.
+func startCPUSampler() {
+	cpuOnce.Do(func() {
+		r, err := newCPUReader()
+		if err != nil {
+			return
+		}
+		go func() {
+			ticker := time.NewTicker(cpuInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				cur, err := r.Usage()
+				if err != nil || cur <= 0 {
+					continue
+				}
+				prev := atomic.LoadInt64(&cpuUsage)
+				atomic.StoreInt64(&cpuUsage, int64(float64(prev)*cpuDecay+float64(cur)*(1-cpuDecay)))
+			}
+		}()
+	})
+}
+
+func loadCPU() int64 {
+	return atomic.LoadInt64(&cpuUsage)
+}
@@ -0,0 +1,165 @@
+package bbr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// newCPUReader prefers the cgroup v2 then v1 accounting, which honours the container quota,
+// and falls back to /proc/stat.
+func newCPUReader() (cpuReader, error) {
+	if r, err := newCgroupV2Reader(); err == nil {
+		return r, nil
+	}
+	if r, err := newCgroupV1Reader(); err == nil {
+		return r, nil
+	}
+	return newProcReader()
+}
+
+// cgroupReader computes the usage from a cumulative cpu time counter and the cores allowed.
+type cgroupReader struct {
+	usage     func() (uint64, error)
+	cores     float64
+	lastUsage uint64
+	lastTime  time.Time
+}
+
+func newCgroupReader(usage func() (uint64, error), cores float64) (*cgroupReader, error) {
+	u, err := usage()
+	if err != nil {
+		return nil, err
+	}
+	if cores <= 0 || cores > float64(runtime.NumCPU()) {
+		cores = float64(runtime.NumCPU())
+	}
+	return &cgroupReader{usage: usage, cores: cores, lastUsage: u, lastTime: time.Now()}, nil
+}
+
+func (r *cgroupReader) Usage() (int64, error) {
+	u, err := r.usage()
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	elapsed := now.Sub(r.lastTime)
+	if elapsed <= 0 || u < r.lastUsage {
+		return 0, errors.New("cpu usage went backwards")
+	}
+	usage := float64(u-r.lastUsage) / (float64(elapsed.Nanoseconds()) * r.cores) * 1000
+	r.lastUsage, r.lastTime = u, now
+	return int64(usage), nil
+}
+
+func newCgroupV2Reader() (cpuReader, error) {
+	cores := 0.0
+	if data, err := os.ReadFile("/sys/fs/cgroup/cpu.max"); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 && fields[0] != "max" {
+			quota, _ := strconv.ParseFloat(fields[0], 64)
+			period, _ := strconv.ParseFloat(fields[1], 64)
+			if period > 0 {
+				cores = quota / period
+			}
+		}
+	}
+	return newCgroupReader(func() (uint64, error) {
+		v, err := readKey("/sys/fs/cgroup/cpu.stat", "usage_usec")
+		return v * uint64(time.Microsecond), err
+	}, cores)
+}
+
+func newCgroupV1Reader() (cpuReader, error) {
+	cores := 0.0
+	quota, qerr := readInt("/sys/fs/cgroup/cpu/cpu.cfs_quota_us")
+	period, perr := readInt("/sys/fs/cgroup/cpu/cpu.cfs_period_us")
+	if qerr == nil && perr == nil && quota > 0 && period > 0 {
+		cores = float64(quota) / float64(period)
+	}
+	return newCgroupReader(func() (uint64, error) {
+		v, err := readInt("/sys/fs/cgroup/cpuacct/cpuacct.usage")
+		return uint64(v), err
+	}, cores)
+}
+
+// procReader computes the usage of the whole host from the busy and total jiffies of /proc/stat.
+type procReader struct {
+	lastBusy, lastTotal uint64
+}
+
+func newProcReader() (cpuReader, error) {
+	busy, total, err := procStat()
+	if err != nil {
+		return nil, err
+	}
+	return &procReader{lastBusy: busy, lastTotal: total}, nil
+}
+
+func (r *procReader) Usage() (int64, error) {
+	busy, total, err := procStat()
+	if err != nil {
+		return 0, err
+	}
+	if total <= r.lastTotal || busy < r.lastBusy {
+		return 0, errors.New("cpu usage went backwards")
+	}
+	usage := float64(busy-r.lastBusy) / float64(total-r.lastTotal) * 1000
+	r.lastBusy, r.lastTotal = busy, total
+	return int64(usage), nil
+}
+
+func procStat() (busy, total uint64, err error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		for i, field := range fields[1:] {
+			v, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return 0, 0, err
+			}
+			total += v
+			// idle and iowait
+			if i != 3 && i != 4 {
+				busy += v
+			}
+		}
+		return busy, total, nil
+	}
+	return 0, 0, fmt.Errorf("no cpu line in /proc/stat")
+}
+
+func readInt(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func readKey(path, key string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			return strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("%s not found in %s", key, path)
+}
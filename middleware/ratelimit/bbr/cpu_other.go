@@ -0,0 +1,11 @@
+//go:build !linux
+
+package bbr
+
+import (
+	"errors"
+)
+
+func newCPUReader() (cpuReader, error) {
+	return nil, errors.New("cpu usage is only read on linux")
+}
@@ -0,0 +1,159 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kanengo/goutil/pkg/log"
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
+	"github.com/kanengo/ngrpc/middleware/ratelimit/leakybucket"
+	"github.com/kanengo/ngrpc/registry"
+	"go.uber.org/zap"
+)
+
+var (
+	_ ratelimit.Waiter        = (*Limiter)(nil)
+	_ ratelimit.QuotaReporter = (*Limiter)(nil)
+)
+
+type Option func(*options)
+
+type options struct {
+	burst time.Duration
+}
+
+// WithBurst with the duration of the local quota allowed in a burst, defaults to 1s.
+func WithBurst(d time.Duration) Option {
+	return func(o *options) {
+		o.burst = d
+	}
+}
+
+// Limiter splits a cluster wide qps evenly across the live instances of a service,
+// the local quota follows the instances joining and leaving the registry.
+type Limiter struct {
+	opts      options
+	qps       int64
+	instances int64
+	bucket    *leakybucket.LeakyBucket
+
+	watcher registry.Watcher
+	ctx     context.Context
+	cancel  context.CancelFunc
+	once    sync.Once
+}
+
+// NewLimiter watches the instances of serviceName to share qps between them,
+// the limiter acts as the only instance until the first update.
+func NewLimiter(ctx context.Context, discovery registry.Discovery, serviceName string, qps int64, opts ...Option) (*Limiter, error) {
+	if qps <= 0 {
+		return nil, errors.New("ratelimit: qps must be positive")
+	}
+	o := options{
+		burst: time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w, err := discovery.Watch(ctx, serviceName)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	l := &Limiter{
+		opts:    o,
+		qps:     qps,
+		watcher: w,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	capacity, fillRate := l.limit(1)
+	l.bucket = leakybucket.NewLeakyBucket(capacity, fillRate)
+	l.instances = 1
+	go l.watch()
+	return l, nil
+}
+
+func (l *Limiter) Allow() error {
+	return l.bucket.Allow()
+}
+
+func (l *Limiter) Wait(ctx context.Context, n int64) error {
+	return l.bucket.Wait(ctx, n)
+}
+
+func (l *Limiter) Quota() ratelimit.Quota {
+	return l.bucket.Quota()
+}
+
+// Instances returns the number of instances the qps is split across.
+func (l *Limiter) Instances() int64 {
+	return atomic.LoadInt64(&l.instances)
+}
+
+// Stop stops watching the registry, the local quota stays as is.
+func (l *Limiter) Stop() error {
+	var err error
+	l.once.Do(func() {
+		l.cancel()
+		err = l.watcher.Stop()
+	})
+	return err
+}
+
+func (l *Limiter) watch() {
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		default:
+		}
+		ins, err := l.watcher.Next()
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			log.Error("[ratelimit] Failed to watch discovery", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		l.update(ins)
+	}
+}
+
+func (l *Limiter) update(ins []*registry.ServiceInstance) {
+	ids := make(map[string]struct{}, len(ins))
+	for _, in := range ins {
+		ids[in.ID] = struct{}{}
+	}
+	// an empty list is likely a registry glitch, this instance is alive at least
+	n := int64(len(ids))
+	if n == 0 {
+		n = 1
+	}
+	if atomic.SwapInt64(&l.instances, n) == n {
+		return
+	}
+	capacity, fillRate := l.limit(n)
+	l.bucket.SetLimit(capacity, fillRate)
+	log.Info("[ratelimit] Cluster quota updated", zap.Int64("instances", n), zap.Int64("capacity", capacity), zap.Duration("fillRate", fillRate))
+}
+
+// limit returns the capacity and fill rate of the local bucket for n instances.
+func (l *Limiter) limit(n int64) (int64, time.Duration) {
+	local := float64(l.qps) / float64(n)
+	capacity := int64(math.Ceil(local * l.opts.burst.Seconds()))
+	if capacity < 1 {
+		capacity = 1
+	}
+	fillRate := time.Duration(float64(time.Second) / local)
+	if fillRate <= 0 {
+		fillRate = 1
+	}
+	return capacity, fillRate
+}
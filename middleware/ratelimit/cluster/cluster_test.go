@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/registry"
+)
+
+type testDiscovery struct {
+	w *testWatcher
+}
+
+func (d *testDiscovery) ListService(context.Context, string) ([]*registry.ServiceInstance, error) {
+	return nil, nil
+}
+
+func (d *testDiscovery) Watch(ctx context.Context, _ string) (registry.Watcher, error) {
+	d.w.ctx = ctx
+	return d.w, nil
+}
+
+type testWatcher struct {
+	ctx context.Context
+	ch  chan []*registry.ServiceInstance
+}
+
+func (w *testWatcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case ins := <-w.ch:
+		return ins, nil
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	}
+}
+
+func (w *testWatcher) Stop() error {
+	return nil
+}
+
+func instances(ids ...string) []*registry.ServiceInstance {
+	ins := make([]*registry.ServiceInstance, 0, len(ids))
+	for _, id := range ids {
+		ins = append(ins, &registry.ServiceInstance{ID: id, Name: "helloworld"})
+	}
+	return ins
+}
+
+func waitInstances(t *testing.T, l *Limiter, n int64) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if l.Instances() == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d instances, got %d", n, l.Instances())
+}
+
+func TestLimiter(t *testing.T) {
+	w := &testWatcher{ch: make(chan []*registry.ServiceInstance)}
+	l, err := NewLimiter(context.Background(), &testDiscovery{w: w}, "helloworld", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Stop()
+	if q := l.Quota(); q.Limit != 100 {
+		t.Fatalf("expected the full quota alone, got %d", q.Limit)
+	}
+
+	w.ch <- instances("a", "b", "c", "d")
+	waitInstances(t, l, 4)
+	if q := l.Quota(); q.Limit != 25 {
+		t.Fatalf("expected a quarter of the quota, got %d", q.Limit)
+	}
+	var allowed int
+	for i := 0; i < 100; i++ {
+		if l.Allow() == nil {
+			allowed++
+		}
+	}
+	if allowed != 25 {
+		t.Fatalf("expected 25 requests to pass, got %d", allowed)
+	}
+
+	w.ch <- instances("a", "b")
+	waitInstances(t, l, 2)
+	if q := l.Quota(); q.Limit != 50 || q.Remaining > 5 {
+		t.Fatalf("expected half of the quota with the tokens left kept, got %+v", q)
+	}
+
+	w.ch <- nil
+	waitInstances(t, l, 1)
+}
+
+func TestLimiterInvalidQPS(t *testing.T) {
+	if _, err := NewLimiter(context.Background(), &testDiscovery{w: &testWatcher{}}, "helloworld", 0); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,103 @@
+package concurrency
+
+import (
+	"math"
+	"time"
+)
+
+// Algorithm computes the concurrency limit from the requests completed.
+// Update is called with the current limit, the rtt of the request, the requests in flight
+// when it started and whether it was dropped, a timeout for instance, and returns the new limit.
+type Algorithm interface {
+	Update(limit float64, rtt time.Duration, inFlight int64, dropped bool) float64
+}
+
+// probeInterval is the number of samples after which the min rtt is measured again,
+// so that the limit follows a slower service instead of sticking to a stale minimum.
+const probeInterval = 1000
+
+// minRTT tracks the lowest rtt observed, the no load latency of the service.
+type minRTT struct {
+	rtt     time.Duration
+	samples int
+}
+
+func (m *minRTT) update(rtt time.Duration) time.Duration {
+	m.samples++
+	if m.samples > probeInterval {
+		m.rtt, m.samples = 0, 0
+	}
+	if m.rtt == 0 || rtt < m.rtt {
+		m.rtt = rtt
+	}
+	return m.rtt
+}
+
+// log10 is the step of the limit, at least 1 so that small limits move.
+func log10(limit float64) float64 {
+	return math.Max(1, math.Floor(math.Log10(limit)))
+}
+
+type vegas struct {
+	min minRTT
+}
+
+// NewVegas returns the TCP Vegas algorithm, it estimates the queue from the rtt
+// over the min rtt and grows the limit while the queue is short and shrinks it when long.
+func NewVegas() Algorithm {
+	return &vegas{}
+}
+
+func (v *vegas) Update(limit float64, rtt time.Duration, inFlight int64, dropped bool) float64 {
+	noLoad := v.min.update(rtt)
+	step := log10(limit)
+	if dropped {
+		return limit - step
+	}
+	// the limit is not the bottleneck while less than half of it is used
+	if float64(inFlight)*2 < limit {
+		return limit
+	}
+	queue := math.Ceil(limit * (1 - float64(noLoad)/float64(rtt)))
+	alpha, beta := 3*step, 6*step
+	switch {
+	case queue <= step:
+		return limit + beta
+	case queue < alpha:
+		return limit + step
+	case queue > beta:
+		return limit - step
+	default:
+		return limit
+	}
+}
+
+const (
+	gradientTolerance = 2.0
+	gradientSmoothing = 0.2
+)
+
+type gradient struct {
+	min minRTT
+}
+
+// NewGradient returns the gradient algorithm, it scales the limit by the ratio of the min rtt
+// to the rtt, tolerating an rtt twice the min one, and leaves room for a queue of sqrt(limit).
+func NewGradient() Algorithm {
+	return &gradient{}
+}
+
+func (g *gradient) Update(limit float64, rtt time.Duration, inFlight int64, dropped bool) float64 {
+	noLoad := g.min.update(rtt)
+	var newLimit float64
+	switch {
+	case dropped:
+		newLimit = limit / 2
+	case float64(inFlight)*2 < limit:
+		return limit
+	default:
+		ratio := math.Max(0.5, math.Min(1, gradientTolerance*float64(noLoad)/float64(rtt)))
+		newLimit = limit*ratio + math.Sqrt(limit)
+	}
+	return limit*(1-gradientSmoothing) + newLimit*gradientSmoothing
+}
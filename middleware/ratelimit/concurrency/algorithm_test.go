@@ -0,0 +1,56 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVegas(t *testing.T) {
+	tests := []struct {
+		name     string
+		rtt      time.Duration
+		inFlight int64
+		dropped  bool
+		want     float64
+	}{
+		{"no queue", 10 * time.Millisecond, 20, false, 26},
+		{"short queue", 11 * time.Millisecond, 20, false, 21},
+		{"steady", 14 * time.Millisecond, 20, false, 20},
+		{"long queue", 20 * time.Millisecond, 20, false, 19},
+		{"dropped", 10 * time.Millisecond, 20, true, 19},
+		{"app limited", 20 * time.Millisecond, 5, false, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVegas()
+			v.Update(20, 10*time.Millisecond, 0, false)
+			if got := v.Update(20, tt.rtt, tt.inFlight, tt.dropped); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGradient(t *testing.T) {
+	tests := []struct {
+		name     string
+		rtt      time.Duration
+		inFlight int64
+		dropped  bool
+		want     float64
+	}{
+		{"tolerated", 20 * time.Millisecond, 16, false, 16.8},
+		{"slow", 80 * time.Millisecond, 16, false, 15.2},
+		{"dropped", 10 * time.Millisecond, 16, true, 14.4},
+		{"app limited", 80 * time.Millisecond, 4, false, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGradient()
+			g.Update(16, 10*time.Millisecond, 0, false)
+			if got := g.Update(16, tt.rtt, tt.inFlight, tt.dropped); got-tt.want > 1e-9 || tt.want-got > 1e-9 {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package concurrency
+
+import (
+	"context"
+	stderrors "errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
+)
+
+var (
+	_ ratelimit.FeedbackLimiter = (*Limiter)(nil)
+)
+
+type Option func(*options)
+
+type options struct {
+	initialLimit int64
+	minLimit     int64
+	maxLimit     int64
+	dropped      func(err error) bool
+}
+
+// WithInitialLimit with the limit before any request completed, defaults to 20.
+func WithInitialLimit(limit int64) Option {
+	return func(o *options) {
+		o.initialLimit = limit
+	}
+}
+
+// WithMinLimit with the lowest limit, defaults to 1.
+func WithMinLimit(limit int64) Option {
+	return func(o *options) {
+		o.minLimit = limit
+	}
+}
+
+// WithMaxLimit with the highest limit, defaults to 1000.
+func WithMaxLimit(limit int64) Option {
+	return func(o *options) {
+		o.maxLimit = limit
+	}
+}
+
+// WithDropped with the errors reported as drops to the algorithm, defaults to timeouts.
+func WithDropped(fn func(err error) bool) Option {
+	return func(o *options) {
+		o.dropped = fn
+	}
+}
+
+// Limiter rejects the requests above an adaptive limit of requests in flight.
+type Limiter struct {
+	opts     options
+	alg      Algorithm
+	inFlight int64
+	// limit is the rounded value of the algorithm limit, read on every request
+	limit int64
+
+	mu       sync.Mutex
+	estimate float64
+}
+
+func NewLimiter(alg Algorithm, opts ...Option) *Limiter {
+	o := options{
+		initialLimit: 20,
+		minLimit:     1,
+		maxLimit:     1000,
+		dropped: func(err error) bool {
+			return stderrors.Is(err, context.DeadlineExceeded) || errors.IsTimeout(err)
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Limiter{
+		opts:     o,
+		alg:      alg,
+		limit:    o.initialLimit,
+		estimate: float64(o.initialLimit),
+	}
+}
+
+func (l *Limiter) Allow() error {
+	if atomic.LoadInt64(&l.inFlight) >= atomic.LoadInt64(&l.limit) {
+		return ratelimit.ErrTriggerLimit
+	}
+	return nil
+}
+
+func (l *Limiter) Acquire() (ratelimit.DoneFunc, error) {
+	inFlight := atomic.AddInt64(&l.inFlight, 1)
+	if inFlight > atomic.LoadInt64(&l.limit) {
+		atomic.AddInt64(&l.inFlight, -1)
+		return nil, ratelimit.ErrTriggerLimit
+	}
+	start := time.Now()
+	return func(err error) {
+		rtt := time.Since(start)
+		atomic.AddInt64(&l.inFlight, -1)
+		l.update(rtt, inFlight, l.opts.dropped(err))
+	}, nil
+}
+
+// Limit returns the current limit of requests in flight.
+func (l *Limiter) Limit() int64 {
+	return atomic.LoadInt64(&l.limit)
+}
+
+// InFlight returns the number of requests in flight.
+func (l *Limiter) InFlight() int64 {
+	return atomic.LoadInt64(&l.inFlight)
+}
+
+func (l *Limiter) update(rtt time.Duration, inFlight int64, dropped bool) {
+	if rtt <= 0 {
+		rtt = 1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	estimate := l.alg.Update(l.estimate, rtt, inFlight, dropped)
+	estimate = math.Max(float64(l.opts.minLimit), math.Min(float64(l.opts.maxLimit), estimate))
+	l.estimate = estimate
+	atomic.StoreInt64(&l.limit, int64(estimate))
+}
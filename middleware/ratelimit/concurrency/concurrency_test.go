@@ -0,0 +1,88 @@
+package concurrency
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type testAlgorithm struct {
+	dropped []bool
+}
+
+func (a *testAlgorithm) Update(limit float64, _ time.Duration, _ int64, dropped bool) float64 {
+	a.dropped = append(a.dropped, dropped)
+	if dropped {
+		return limit - 1
+	}
+	return limit + 1
+}
+
+func TestLimiter(t *testing.T) {
+	alg := &testAlgorithm{}
+	l := NewLimiter(alg, WithInitialLimit(2), WithMaxLimit(3))
+
+	var dones []ratelimit.DoneFunc
+	for i := 0; i < 2; i++ {
+		done, err := l.Acquire()
+		if err != nil {
+			t.Fatalf("expected request %d to pass, got %v", i, err)
+		}
+		dones = append(dones, done)
+	}
+	if _, err := l.Acquire(); !errors.IsServiceUnavailable(err) {
+		t.Fatalf("expected service unavailable above the limit, got %v", err)
+	}
+	if err := l.Allow(); err == nil {
+		t.Fatal("expected Allow to report the limit")
+	}
+	if l.InFlight() != 2 {
+		t.Fatalf("expected 2 in flight, got %d", l.InFlight())
+	}
+
+	dones[0](nil)
+	dones[1](context.DeadlineExceeded)
+	if l.InFlight() != 0 {
+		t.Fatalf("expected 0 in flight, got %d", l.InFlight())
+	}
+	if len(alg.dropped) != 2 || alg.dropped[0] || !alg.dropped[1] {
+		t.Fatalf("expected the timeout to be reported as a drop, got %v", alg.dropped)
+	}
+	if l.Limit() != 2 {
+		t.Fatalf("expected limit 2, got %d", l.Limit())
+	}
+
+	for i := 0; i < 5; i++ {
+		done, err := l.Acquire()
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(nil)
+	}
+	if l.Limit() != 3 {
+		t.Fatalf("expected the limit capped to 3, got %d", l.Limit())
+	}
+}
+
+func TestCollector(t *testing.T) {
+	l := NewLimiter(NewGradient(), WithInitialLimit(5))
+	if _, err := l.Acquire(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+		# HELP ngrpc_concurrency_limiter_in_flight The requests in flight.
+		# TYPE ngrpc_concurrency_limiter_in_flight gauge
+		ngrpc_concurrency_limiter_in_flight{limiter="server"} 1
+		# HELP ngrpc_concurrency_limiter_limit The limit of requests in flight.
+		# TYPE ngrpc_concurrency_limiter_limit gauge
+		ngrpc_concurrency_limiter_limit{limiter="server"} 5
+	`
+	if err := testutil.CollectAndCompare(NewCollector(l, "ngrpc", "server"), strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,31 @@
+package concurrency
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type collector struct {
+	l        *Limiter
+	limit    *prometheus.Desc
+	inFlight *prometheus.Desc
+}
+
+// NewCollector returns a prometheus collector exporting the limit and the requests in flight of l.
+func NewCollector(l *Limiter, namespace, name string) prometheus.Collector {
+	labels := prometheus.Labels{"limiter": name}
+	return &collector{
+		l:        l,
+		limit:    prometheus.NewDesc(prometheus.BuildFQName(namespace, "concurrency_limiter", "limit"), "The limit of requests in flight.", nil, labels),
+		inFlight: prometheus.NewDesc(prometheus.BuildFQName(namespace, "concurrency_limiter", "in_flight"), "The requests in flight.", nil, labels),
+	}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.limit
+	ch <- c.inFlight
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.limit, prometheus.GaugeValue, float64(c.l.Limit()))
+	ch <- prometheus.MustNewConstMetric(c.inFlight, prometheus.GaugeValue, float64(c.l.InFlight()))
+}
@@ -0,0 +1,255 @@
+package keyed
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/metadata"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
+	"github.com/kanengo/ngrpc/middleware/ratelimit/leakybucket"
+	"github.com/kanengo/ngrpc/transport"
+	"google.golang.org/grpc/peer"
+)
+
+// ReasonEmptyKey is the reason of ErrEmptyKey.
+const ReasonEmptyKey = "RATELIMIT_EMPTY_KEY"
+
+// ErrEmptyKey is returned for the requests without a key when WithRejectEmptyKey is set.
+var ErrEmptyKey = errors.BadRequest("rate limit key is missing").WithReason(ReasonEmptyKey)
+
+// KeyFunc derives the limiting key of a request, an empty key means the request has none.
+type KeyFunc func(ctx context.Context) string
+
+// Header keys requests on a request header.
+func Header(name string) KeyFunc {
+	return func(ctx context.Context) string {
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			return tr.RequestHeader().Get(name)
+		}
+		return ""
+	}
+}
+
+// Metadata keys requests on a server metadata value.
+func Metadata(key string) KeyFunc {
+	return func(ctx context.Context) string {
+		if md, ok := metadata.FromServerContext(ctx); ok {
+			return md.Get(key)
+		}
+		return ""
+	}
+}
+
+// PeerIP keys requests on the ip of the remote peer.
+func PeerIP() KeyFunc {
+	return func(ctx context.Context) string {
+		var addr string
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			addr = p.Addr.String()
+		} else if tr, ok := transport.FromServerContext(ctx); ok {
+			if r, ok := tr.(interface{ Request() *http.Request }); ok && r.Request() != nil {
+				addr = r.Request().RemoteAddr
+			}
+		}
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			return host
+		}
+		return addr
+	}
+}
+
+// Method keys requests on the full method.
+func Method() KeyFunc {
+	return func(ctx context.Context) string {
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			return tr.FullMethod()
+		}
+		return ""
+	}
+}
+
+// Limit is the capacity and fill rate of the bucket of a key.
+type Limit struct {
+	Capacity int64
+	FillRate time.Duration
+}
+
+type Option func(*options)
+
+type options struct {
+	size        int
+	idleTimeout time.Duration
+	overrides   map[string]Limit
+	limiters    map[string]ratelimit.Limiter
+	newLimiter  func(Limit) ratelimit.Limiter
+}
+
+// WithSize with the max number of keys tracked, the least recently used one is dropped when full, defaults to 10000.
+// A dropped key starts over with a full bucket, so the size should cover the active keys.
+func WithSize(size int) Option {
+	return func(o *options) {
+		o.size = size
+	}
+}
+
+// WithIdleTimeout with the duration after which the limiter of an unused key is dropped, defaults to 10m.
+// It should be longer than the time the bucket takes to refill, as the key starts over with a full bucket.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = d
+	}
+}
+
+// WithOverride with the limit of key instead of the default one.
+func WithOverride(key string, limit Limit) Option {
+	return func(o *options) {
+		o.overrides[key] = limit
+	}
+}
+
+// WithLimiter with the limiter of key, it is never dropped. Along with Method it picks the
+// limiting algorithm per method, such as a Pacer for strict pacing.
+func WithLimiter(key string, limiter ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.limiters[key] = limiter
+	}
+}
+
+// WithNewLimiter with the constructor of the per-key limiters, defaults to a leaky bucket.
+func WithNewLimiter(fn func(Limit) ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.newLimiter = fn
+	}
+}
+
+type entry struct {
+	key        string
+	limiter    ratelimit.Limiter
+	lastAccess time.Time
+}
+
+// Limiter holds a limiter per key. The limiter of a key dropped for room or idleness
+// is created again on its next request, with a full bucket.
+type Limiter struct {
+	opts  options
+	limit Limit
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+// NewLimiter returns a Limiter creating the limiter of each key with limit unless overridden.
+func NewLimiter(limit Limit, opts ...Option) *Limiter {
+	o := options{
+		size:        10000,
+		idleTimeout: 10 * time.Minute,
+		overrides:   make(map[string]Limit),
+		limiters:    make(map[string]ratelimit.Limiter),
+		newLimiter: func(l Limit) ratelimit.Limiter {
+			return leakybucket.NewLeakyBucket(l.Capacity, l.FillRate)
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Limiter{
+		opts:  o,
+		limit: limit,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the limiter of key, creating it on the first call or after it expired.
+func (l *Limiter) Get(key string) ratelimit.Limiter {
+	if lim, ok := l.opts.limiters[key]; ok {
+		return lim
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expire(now)
+	if e, ok := l.items[key]; ok {
+		ent := e.Value.(*entry)
+		ent.lastAccess = now
+		l.lru.MoveToFront(e)
+		return ent.limiter
+	}
+	limit, ok := l.opts.overrides[key]
+	if !ok {
+		limit = l.limit
+	}
+	ent := &entry{key: key, limiter: l.opts.newLimiter(limit), lastAccess: now}
+	l.items[key] = l.lru.PushFront(ent)
+	if l.opts.size > 0 && l.lru.Len() > l.opts.size {
+		l.remove(l.lru.Back())
+	}
+	return ent.limiter
+}
+
+// Len returns the number of keys tracked.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lru.Len()
+}
+
+// expire drops the idle keys, they are at the back of the list.
+func (l *Limiter) expire(now time.Time) {
+	if l.opts.idleTimeout <= 0 {
+		return
+	}
+	for e := l.lru.Back(); e != nil && now.Sub(e.Value.(*entry).lastAccess) > l.opts.idleTimeout; e = l.lru.Back() {
+		l.remove(e)
+	}
+}
+
+func (l *Limiter) remove(e *list.Element) {
+	l.lru.Remove(e)
+	delete(l.items, e.Value.(*entry).key)
+}
+
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	rejectEmpty bool
+}
+
+// WithRejectEmptyKey makes Server reject the requests without a key with ErrEmptyKey,
+// they are not limited otherwise, unless the limiter has a limiter for "" set with WithLimiter.
+func WithRejectEmptyKey() ServerOption {
+	return func(o *serverOptions) {
+		o.rejectEmpty = true
+	}
+}
+
+// Server limits the requests with the limiter of their key. The requests without a key
+// are passed through, or rejected with WithRejectEmptyKey, rather than sharing one bucket.
+func Server(keyFunc KeyFunc, limiter *Limiter, opts ...ServerOption) middleware.Middleware {
+	o := serverOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			key := keyFunc(ctx)
+			if key == "" {
+				if lim, ok := limiter.opts.limiters[key]; ok {
+					return ratelimit.Handle(ctx, lim, req, handler)
+				}
+				if o.rejectEmpty {
+					return nil, ErrEmptyKey
+				}
+				return handler(ctx, req)
+			}
+			return ratelimit.Handle(ctx, limiter.Get(key), req, handler)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package keyed
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/metadata"
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
+	"github.com/kanengo/ngrpc/middleware/ratelimit/leakybucket"
+	"github.com/kanengo/ngrpc/transport"
+	"google.golang.org/grpc/peer"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string { return hc[key] }
+
+func (hc headerCarrier) Set(key, value string) { hc[key] = value }
+
+func (hc headerCarrier) Keys() []string { return nil }
+
+type testTransport struct {
+	header headerCarrier
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *testTransport) Endpoint() string                { return "grpc://127.0.0.1:9000" }
+func (tr *testTransport) FullMethod() string              { return "/helloworld.Greeter/SayHello" }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+func (tr *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+func TestKeyFunc(t *testing.T) {
+	ctx := transport.NewServerContext(context.Background(), &testTransport{header: headerCarrier{"x-tenant": "a"}})
+	ctx = metadata.NewServerContext(ctx, metadata.New(map[string]string{"x-md-global-tenant": "b"}))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+
+	tests := []struct {
+		name    string
+		keyFunc KeyFunc
+		want    string
+	}{
+		{"header", Header("x-tenant"), "a"},
+		{"metadata", Metadata("x-md-global-tenant"), "b"},
+		{"peer", PeerIP(), "10.0.0.1"},
+		{"method", Method(), "/helloworld.Greeter/SayHello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.keyFunc(ctx); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+	if got := Header("x-tenant")(context.Background()); got != "" {
+		t.Errorf("expected empty key without transport, got %q", got)
+	}
+}
+
+func TestServer(t *testing.T) {
+	limiter := NewLimiter(Limit{Capacity: 2, FillRate: time.Hour}, WithOverride("vip", Limit{Capacity: 5, FillRate: time.Hour}))
+	h := Server(Header("x-tenant"), limiter)(func(context.Context, any) (any, error) { return "reply", nil })
+
+	call := func(tenant string) error {
+		ctx := transport.NewServerContext(context.Background(), &testTransport{header: headerCarrier{"x-tenant": tenant}})
+		_, err := h(ctx, nil)
+		return err
+	}
+	for tenant, capacity := range map[string]int{"a": 2, "b": 2, "vip": 5} {
+		for i := 0; i < capacity; i++ {
+			if err := call(tenant); err != nil {
+				t.Fatalf("%s: expected request %d to pass, got %v", tenant, i, err)
+			}
+		}
+		if err := call(tenant); !errors.Is(err, ratelimit.ErrTriggerLimit) {
+			t.Fatalf("%s: expected %v, got %v", tenant, ratelimit.ErrTriggerLimit, err)
+		}
+	}
+}
+
+func TestServerEmptyKey(t *testing.T) {
+	handler := func(context.Context, any) (any, error) { return "reply", nil }
+	ctx := transport.NewServerContext(context.Background(), &testTransport{header: headerCarrier{}})
+
+	limiter := NewLimiter(Limit{Capacity: 1, FillRate: time.Hour})
+	h := Server(Header("x-tenant"), limiter)(handler)
+	for i := 0; i < 3; i++ {
+		if _, err := h(ctx, nil); err != nil {
+			t.Fatalf("expected requests without a key to pass, got %v", err)
+		}
+	}
+	if limiter.Len() != 0 {
+		t.Fatalf("expected no bucket for the empty key, got %d keys", limiter.Len())
+	}
+
+	h = Server(Header("x-tenant"), limiter, WithRejectEmptyKey())(handler)
+	if _, err := h(ctx, nil); !errors.Is(err, ErrEmptyKey) {
+		t.Fatalf("expected %v, got %v", ErrEmptyKey, err)
+	}
+
+	h = Server(Header("x-tenant"), NewLimiter(Limit{Capacity: 1, FillRate: time.Hour},
+		WithLimiter("", leakybucket.NewLeakyBucket(1, time.Hour))), WithRejectEmptyKey())(handler)
+	if _, err := h(ctx, nil); err != nil {
+		t.Fatalf("expected the empty key limiter to allow, got %v", err)
+	}
+	if _, err := h(ctx, nil); !errors.Is(err, ratelimit.ErrTriggerLimit) {
+		t.Fatalf("expected %v, got %v", ratelimit.ErrTriggerLimit, err)
+	}
+}
+
+func TestLimiterEviction(t *testing.T) {
+	limiter := NewLimiter(Limit{Capacity: 1, FillRate: time.Hour}, WithSize(2), WithIdleTimeout(50*time.Millisecond))
+	a := limiter.Get("a")
+	limiter.Get("b")
+	if limiter.Get("a") != a {
+		t.Fatal("expected the limiter of a to be reused")
+	}
+	limiter.Get("c")
+	if limiter.Len() != 2 {
+		t.Fatalf("expected 2 keys, got %d", limiter.Len())
+	}
+	if _, ok := limiter.items["b"]; ok {
+		t.Fatal("expected the least recently used key b to be dropped")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	limiter.Get("d")
+	if limiter.Len() != 1 {
+		t.Fatalf("expected the idle keys to expire, got %d keys", limiter.Len())
+	}
+}
+
+func TestLimiterFixed(t *testing.T) {
+	pacer := leakybucket.NewPacer(time.Second)
+	limiter := NewLimiter(Limit{Capacity: 1, FillRate: time.Hour}, WithSize(1), WithLimiter("/helloworld.Greeter/SayHello", pacer))
+	limiter.Get("a")
+	if limiter.Get("/helloworld.Greeter/SayHello") != pacer {
+		t.Fatal("expected the fixed limiter")
+	}
+	if limiter.Len() != 1 {
+		t.Fatalf("expected the fixed limiter not to be tracked, got %d keys", limiter.Len())
+	}
+}
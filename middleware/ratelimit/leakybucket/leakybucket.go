@@ -1,6 +1,7 @@
 package leakybucket
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -8,7 +9,8 @@ import (
 )
 
 var (
-	_ ratelimit.Limiter = (*LeakyBucket)(nil)
+	_ ratelimit.Waiter        = (*LeakyBucket)(nil)
+	_ ratelimit.QuotaReporter = (*LeakyBucket)(nil)
 )
 
 type LeakyBucket struct {
@@ -35,6 +37,18 @@ func NewLeakyBucket(capacity int64, fillRate time.Duration) *LeakyBucket {
 	}
 }
 
+// SetLimit changes the capacity and fill rate, the tokens left are kept up to the new capacity.
+func (lb *LeakyBucket) SetLimit(capacity int64, fillRate time.Duration) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.refill()
+	lb.capacity = capacity
+	lb.fillRate = fillRate
+	if lb.remainingTokens > capacity {
+		lb.remainingTokens = capacity
+	}
+}
+
 func (lb *LeakyBucket) refill() {
 	now := time.Now()
 	elapsed := now.Sub(lb.lastFilled)
@@ -69,3 +83,62 @@ func (lb *LeakyBucket) GetWaitTime(n int64) time.Duration {
 	neededTokens := n - lb.remainingTokens
 	return time.Duration(neededTokens) * lb.fillRate
 }
+
+func (lb *LeakyBucket) Quota() ratelimit.Quota {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.refill()
+	// the progress towards the next token
+	progress := time.Since(lb.lastFilled)
+	q := ratelimit.Quota{
+		Limit:     lb.capacity,
+		Remaining: lb.remainingTokens,
+	}
+	if q.Remaining < lb.capacity {
+		q.Reset = time.Duration(lb.capacity-lb.remainingTokens)*lb.fillRate - progress
+	}
+	if q.Remaining < 1 {
+		q.Remaining = 0
+		q.RetryAfter = time.Duration(1-lb.remainingTokens)*lb.fillRate - progress
+	}
+	return q
+}
+
+// Wait reserves n tokens and blocks until they are filled. Tokens are handed out in the order
+// of the calls, it fails without reserving if they would not be filled before the context deadline.
+func (lb *LeakyBucket) Wait(ctx context.Context, n int64) error {
+	lb.mu.Lock()
+	if n > lb.capacity {
+		lb.mu.Unlock()
+		return ratelimit.ErrTriggerLimit
+	}
+	lb.refill()
+	if lb.remainingTokens >= n {
+		lb.remainingTokens -= n
+		lb.mu.Unlock()
+		return nil
+	}
+	wait := time.Duration(n-lb.remainingTokens)*lb.fillRate - time.Since(lb.lastFilled)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		lb.mu.Unlock()
+		return ratelimit.ErrTriggerLimit
+	}
+	// the reservation makes the remaining tokens negative until they are filled
+	lb.remainingTokens -= n
+	lb.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		lb.mu.Lock()
+		lb.remainingTokens += n
+		if lb.remainingTokens > lb.capacity {
+			lb.remainingTokens = lb.capacity
+		}
+		lb.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -1,9 +1,12 @@
 package leakybucket
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
 )
 
 func TestLeakyBucket(t *testing.T) {
@@ -30,3 +33,48 @@ func TestLeakyBucket(t *testing.T) {
 		//}
 	}
 }
+
+func TestLeakyBucketWait(t *testing.T) {
+	bucket := NewLeakyBucket(1, time.Millisecond*50)
+	if err := bucket.Wait(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := bucket.Wait(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*40 {
+		t.Fatalf("expected to wait for a token, waited %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := bucket.Wait(ctx, 1); err != ratelimit.ErrTriggerLimit {
+		t.Fatalf("expected %v, got %v", ratelimit.ErrTriggerLimit, err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		cancel()
+	}()
+	if err := bucket.Wait(ctx, 1); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if bucket.remainingTokens != 0 {
+		t.Fatalf("expected the cancelled reservation to be returned, remaining %d", bucket.remainingTokens)
+	}
+}
+
+func TestLeakyBucketQuota(t *testing.T) {
+	bucket := NewLeakyBucket(2, time.Second)
+	if q := bucket.Quota(); q.Limit != 2 || q.Remaining != 2 || q.Reset != 0 || q.RetryAfter != 0 {
+		t.Fatalf("unexpected quota of a full bucket %+v", q)
+	}
+	bucket.TryAcquire(2)
+	q := bucket.Quota()
+	if q.Remaining != 0 || q.Reset <= time.Second || q.Reset > 2*time.Second || q.RetryAfter <= 0 || q.RetryAfter > time.Second {
+		t.Fatalf("unexpected quota of an empty bucket %+v", q)
+	}
+}
@@ -0,0 +1,86 @@
+package leakybucket
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
+)
+
+var (
+	_ ratelimit.Waiter        = (*Pacer)(nil)
+	_ ratelimit.QuotaReporter = (*Pacer)(nil)
+)
+
+// Pacer is a leaky bucket letting requests out evenly, one per interval, without bursts.
+// Allow rejects the requests arriving before their slot, Wait delays them to it.
+type Pacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func NewPacer(interval time.Duration) *Pacer {
+	return &Pacer{interval: interval}
+}
+
+func (p *Pacer) Allow() error {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if now.Before(p.next) {
+		return ratelimit.ErrTriggerLimit
+	}
+	p.next = now.Add(p.interval)
+	return nil
+}
+
+func (p *Pacer) Quota() ratelimit.Quota {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	q := ratelimit.Quota{Limit: 1, Remaining: 1}
+	if wait := time.Until(p.next); wait > 0 {
+		q.Remaining = 0
+		q.Reset = wait
+		q.RetryAfter = wait
+	}
+	return q
+}
+
+// Wait reserves the next n slots and blocks until the first one, it fails without reserving
+// if the slot comes after the context deadline.
+func (p *Pacer) Wait(ctx context.Context, n int64) error {
+	now := time.Now()
+	p.mu.Lock()
+	slot := p.next
+	if slot.Before(now) {
+		slot = now
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(slot) {
+		p.mu.Unlock()
+		return ratelimit.ErrTriggerLimit
+	}
+	next := slot.Add(time.Duration(n) * p.interval)
+	p.next = next
+	p.mu.Unlock()
+
+	wait := slot.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		// give the slots back unless later requests were queued behind them
+		if p.next.Equal(next) {
+			p.next = slot
+		}
+		p.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,55 @@
+package leakybucket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
+)
+
+func TestPacerAllow(t *testing.T) {
+	p := NewPacer(20 * time.Millisecond)
+	if err := p.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Allow(); err != ratelimit.ErrTriggerLimit {
+		t.Fatalf("expected no burst, got %v", err)
+	}
+	time.Sleep(25 * time.Millisecond)
+	if err := p.Allow(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPacerWait(t *testing.T) {
+	p := NewPacer(20 * time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := p.Wait(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("expected requests spaced by 20ms, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := p.Wait(ctx, 1); err != ratelimit.ErrTriggerLimit {
+		t.Fatalf("expected %v, got %v", ratelimit.ErrTriggerLimit, err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	next := p.next
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		cancel()
+	}()
+	if err := p.Wait(ctx, 1); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if !p.next.Equal(next) {
+		t.Fatal("expected the cancelled slot to be given back")
+	}
+}
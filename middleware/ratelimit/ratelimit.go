@@ -2,24 +2,187 @@ package ratelimit
 
 import (
 	"context"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/kanengo/ngrpc/errors"
 	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/transport"
 )
 
 type Limiter interface {
 	Allow() error
 }
 
+// DoneFunc reports the result of a request admitted by Acquire.
+type DoneFunc func(err error)
+
+// FeedbackLimiter is a Limiter that measures the requests it admits,
+// RateLimit calls Acquire instead of Allow and the done func once the request is handled.
+type FeedbackLimiter interface {
+	Limiter
+	Acquire() (DoneFunc, error)
+}
+
+// Waiter is a Limiter able to block until n tokens are available.
+// Wait fails at once if the tokens would not be available before the context deadline.
+type Waiter interface {
+	Limiter
+	Wait(ctx context.Context, n int64) error
+}
+
+// Quota is the state of a limiter.
+type Quota struct {
+	// Limit is the number of requests allowed in a burst or window.
+	Limit int64
+	// Remaining is the number of requests allowed right now.
+	Remaining int64
+	// Reset is the time until the full quota is available again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed.
+	RetryAfter time.Duration
+}
+
+// QuotaReporter is a Limiter exposing its quota, RateLimit reports it in the reply header
+// and in the metadata of ErrTriggerLimit.
+type QuotaReporter interface {
+	Limiter
+	Quota() Quota
+}
+
+const (
+	HeaderLimit      = "x-ratelimit-limit"
+	HeaderRemaining  = "x-ratelimit-remaining"
+	HeaderReset      = "x-ratelimit-reset"
+	HeaderRetryAfter = "retry-after"
+)
+
 var ErrTriggerLimit = errors.ServiceUnavailable("Trigger server limit, please try again later")
 
-func RateLimit(limiter Limiter) middleware.Middleware {
+// ReasonWaitAborted is the reason of the errors returned when the context ends while waiting for a token.
+const ReasonWaitAborted = "RATELIMIT_WAIT_ABORTED"
+
+type Option func(*options)
+
+type options struct {
+	wait       bool
+	maxWaiters int64
+}
+
+// WithWait makes requests wait for a token instead of being rejected when the limiter is a Waiter,
+// at most maxWaiters requests wait at the same time, the others are allowed or rejected at once.
+// A maxWaiters of 0 or less disables the wait mode.
+func WithWait(maxWaiters int64) Option {
+	return func(o *options) {
+		o.wait = maxWaiters > 0
+		o.maxWaiters = maxWaiters
+	}
+}
+
+func RateLimit(limiter Limiter, opts ...Option) middleware.Middleware {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	waiter, _ := limiter.(Waiter)
+	var waiters int64
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req any) (any, error) {
-			if err := limiter.Allow(); err != nil {
-				return nil, ErrTriggerLimit
+			if o.wait && waiter != nil {
+				if atomic.AddInt64(&waiters, 1) > o.maxWaiters {
+					atomic.AddInt64(&waiters, -1)
+					return Handle(ctx, limiter, req, handler)
+				}
+				err := waiter.Wait(ctx, 1)
+				atomic.AddInt64(&waiters, -1)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil, aborted(ctx.Err())
+					}
+					return nil, reject(ctx, limiter)
+				}
+				report(ctx, limiter)
+				return handler(ctx, req)
 			}
-			return handler(ctx, req)
+			return Handle(ctx, limiter, req, handler)
+		}
+	}
+}
+
+// Handle runs handler if limiter allows the request and reports the quota of the limiter.
+func Handle(ctx context.Context, limiter Limiter, req any, handler middleware.Handler) (reply any, err error) {
+	fl, ok := limiter.(FeedbackLimiter)
+	if !ok {
+		if err := limiter.Allow(); err != nil {
+			return nil, reject(ctx, limiter)
+		}
+		report(ctx, limiter)
+		return handler(ctx, req)
+	}
+	done, err := fl.Acquire()
+	if err != nil {
+		return nil, reject(ctx, limiter)
+	}
+	// deferred so a panicking handler still releases the request
+	defer func() { done(err) }()
+	report(ctx, limiter)
+	return handler(ctx, req)
+}
+
+// aborted converts the error of a context ended while waiting into *errors.Error.
+func aborted(err error) error {
+	if err == context.DeadlineExceeded {
+		return errors.Timeout("deadline exceeded while waiting for the rate limit").WithReason(ReasonWaitAborted).WithCause(err)
+	}
+	return errors.ClientClosed("canceled while waiting for the rate limit").WithReason(ReasonWaitAborted).WithCause(err)
+}
+
+// report writes the quota of limiter into the reply header.
+func report(ctx context.Context, limiter Limiter) {
+	qr, ok := limiter.(QuotaReporter)
+	if !ok {
+		return
+	}
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		for k, v := range quotaHeader(qr.Quota()) {
+			tr.ReplyHeader().Set(k, v)
+		}
+	}
+}
+
+// reject returns ErrTriggerLimit, with the quota of limiter in its metadata and the reply header.
+func reject(ctx context.Context, limiter Limiter) error {
+	qr, ok := limiter.(QuotaReporter)
+	if !ok {
+		return ErrTriggerLimit
+	}
+	md := quotaHeader(qr.Quota())
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		for k, v := range md {
+			tr.ReplyHeader().Set(k, v)
 		}
 	}
+	return ErrTriggerLimit.WithMetadata(md)
+}
+
+func quotaHeader(q Quota) map[string]string {
+	md := map[string]string{
+		HeaderLimit:     strconv.FormatInt(q.Limit, 10),
+		HeaderRemaining: strconv.FormatInt(q.Remaining, 10),
+		HeaderReset:     seconds(q.Reset),
+	}
+	if q.Remaining <= 0 {
+		md[HeaderRetryAfter] = seconds(q.RetryAfter)
+	}
+	return md
+}
+
+// seconds rounds d up to whole seconds, as retry-after does not allow fractions.
+func seconds(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
 }
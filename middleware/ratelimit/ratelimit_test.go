@@ -0,0 +1,170 @@
+package ratelimit
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/transport"
+)
+
+type testWaiter struct {
+	release chan struct{}
+}
+
+func (w *testWaiter) Allow() error {
+	return ErrTriggerLimit
+}
+
+func (w *testWaiter) Wait(ctx context.Context, _ int64) error {
+	select {
+	case <-w.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	handler := func(context.Context, any) (any, error) { return "reply", nil }
+
+	w := &testWaiter{release: make(chan struct{})}
+	if _, err := RateLimit(w)(handler)(context.Background(), nil); err != ErrTriggerLimit {
+		t.Fatalf("expected %v without wait mode, got %v", ErrTriggerLimit, err)
+	}
+
+	h := RateLimit(w, WithWait(2))(handler)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if reply, err := h(context.Background(), nil); err != nil || reply != "reply" {
+				t.Errorf("expected reply, got %v %v", reply, err)
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond * 20)
+	if _, err := h(context.Background(), nil); err != ErrTriggerLimit {
+		t.Fatalf("expected %v when the queue is full, got %v", ErrTriggerLimit, err)
+	}
+	close(w.release)
+	wg.Wait()
+
+	w = &testWaiter{release: make(chan struct{})}
+	h = RateLimit(w, WithWait(1))(handler)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := h(ctx, nil); !errors.IsClientClosed(err) || errors.Reason(err) != ReasonWaitAborted {
+		t.Fatalf("expected a client closed error, got %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := h(ctx, nil); !errors.IsTimeout(err) || errors.Reason(err) != ReasonWaitAborted {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+}
+
+type testAllowWaiter struct{}
+
+func (w *testAllowWaiter) Allow() error { return nil }
+
+func (w *testAllowWaiter) Wait(ctx context.Context, _ int64) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRateLimitNoWait(t *testing.T) {
+	handler := func(context.Context, any) (any, error) { return "reply", nil }
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if reply, err := RateLimit(&testAllowWaiter{}, WithWait(0))(handler)(ctx, nil); err != nil || reply != "reply" {
+		t.Fatalf("expected WithWait(0) to allow without waiting, got %v %v", reply, err)
+	}
+}
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string { return hc[key] }
+
+func (hc headerCarrier) Set(key, value string) { hc[key] = value }
+
+func (hc headerCarrier) Keys() []string { return nil }
+
+type testTransport struct {
+	reply headerCarrier
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *testTransport) Endpoint() string                { return "http://127.0.0.1:8000" }
+func (tr *testTransport) FullMethod() string              { return "/helloworld.Greeter/SayHello" }
+func (tr *testTransport) RequestHeader() transport.Header { return headerCarrier{} }
+func (tr *testTransport) ReplyHeader() transport.Header   { return tr.reply }
+
+type testReporter struct {
+	remaining int64
+}
+
+func (r *testReporter) Allow() error {
+	if r.remaining <= 0 {
+		return ErrTriggerLimit
+	}
+	r.remaining--
+	return nil
+}
+
+func (r *testReporter) Quota() Quota {
+	return Quota{Limit: 2, Remaining: r.remaining, Reset: 1500 * time.Millisecond, RetryAfter: 200 * time.Millisecond}
+}
+
+func TestRateLimitQuota(t *testing.T) {
+	h := RateLimit(&testReporter{remaining: 1})(func(context.Context, any) (any, error) { return "reply", nil })
+
+	tr := &testTransport{reply: headerCarrier{}}
+	if _, err := h(transport.NewServerContext(context.Background(), tr), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := headerCarrier{HeaderLimit: "2", HeaderRemaining: "0", HeaderReset: "2", HeaderRetryAfter: "1"}
+	if !reflect.DeepEqual(tr.reply, want) {
+		t.Fatalf("expected header %v, got %v", want, tr.reply)
+	}
+
+	tr = &testTransport{reply: headerCarrier{}}
+	_, err := h(transport.NewServerContext(context.Background(), tr), nil)
+	if !errors.Is(err, ErrTriggerLimit) {
+		t.Fatalf("expected %v, got %v", ErrTriggerLimit, err)
+	}
+	if !reflect.DeepEqual(tr.reply, want) {
+		t.Fatalf("expected header %v, got %v", want, tr.reply)
+	}
+	if md := errors.FromError(err).Metadata; !reflect.DeepEqual(md, map[string]string(want)) {
+		t.Fatalf("expected metadata %v, got %v", want, md)
+	}
+}
+
+type testFeedback struct {
+	inflight int64
+}
+
+func (l *testFeedback) Allow() error { return nil }
+
+func (l *testFeedback) Acquire() (DoneFunc, error) {
+	l.inflight++
+	return func(error) { l.inflight-- }, nil
+}
+
+func TestHandlePanic(t *testing.T) {
+	l := &testFeedback{}
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = Handle(context.Background(), l, nil, func(context.Context, any) (any, error) {
+			panic("boom")
+		})
+	}()
+	if l.inflight != 0 {
+		t.Fatalf("expected the panicking request released, got %d in flight", l.inflight)
+	}
+}
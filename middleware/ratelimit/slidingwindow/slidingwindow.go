@@ -0,0 +1,148 @@
+package slidingwindow
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
+)
+
+var (
+	_ ratelimit.QuotaReporter = (*Counter)(nil)
+	_ ratelimit.QuotaReporter = (*Log)(nil)
+)
+
+// Counter allows about limit requests in any window, it weights the count of the previous fixed window
+// by its overlap with the sliding one so it only keeps two counters.
+type Counter struct {
+	mu     sync.Mutex
+	limit  int64
+	window time.Duration
+	start  time.Time
+	prev   int64
+	cur    int64
+	now    func() time.Time
+}
+
+func NewCounter(limit int64, window time.Duration) *Counter {
+	return &Counter{
+		limit:  limit,
+		window: window,
+		start:  time.Now().Truncate(window),
+		now:    time.Now,
+	}
+}
+
+func (c *Counter) Allow() error {
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.estimate(now) >= float64(c.limit) {
+		return ratelimit.ErrTriggerLimit
+	}
+	c.cur++
+	return nil
+}
+
+func (c *Counter) Quota() ratelimit.Quota {
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	estimate := c.estimate(now)
+	q := ratelimit.Quota{
+		Limit:     c.limit,
+		Remaining: int64(math.Ceil(float64(c.limit) - estimate)),
+	}
+	end := c.start.Add(c.window)
+	// the current count weighs until the end of the next window
+	if c.cur > 0 {
+		q.Reset = end.Add(c.window).Sub(now)
+	} else if c.prev > 0 {
+		q.Reset = end.Sub(now)
+	}
+	if q.Remaining <= 0 {
+		q.Remaining = 0
+		q.RetryAfter = end.Sub(now)
+		// the weight of the previous window may fall low enough before the end of the current one
+		if c.cur < c.limit && c.prev > 0 {
+			weight := float64(c.limit-c.cur) / float64(c.prev)
+			q.RetryAfter = c.start.Add(time.Duration((1 - weight) * float64(c.window))).Sub(now)
+		}
+		if q.RetryAfter < 0 {
+			q.RetryAfter = 0
+		}
+	}
+	return q
+}
+
+// estimate rolls the fixed windows up to now and returns the count of the sliding window.
+func (c *Counter) estimate(now time.Time) float64 {
+	if elapsed := now.Sub(c.start); elapsed >= c.window {
+		if elapsed < 2*c.window {
+			c.prev = c.cur
+		} else {
+			c.prev = 0
+		}
+		c.cur = 0
+		c.start = now.Truncate(c.window)
+	}
+	weight := 1 - float64(now.Sub(c.start))/float64(c.window)
+	return float64(c.prev)*weight + float64(c.cur)
+}
+
+// Log allows exactly limit requests in any window, it keeps the time of the last limit requests.
+type Log struct {
+	mu     sync.Mutex
+	window time.Duration
+	times  []time.Time
+	next   int
+	now    func() time.Time
+}
+
+func NewLog(limit int64, window time.Duration) *Log {
+	return &Log{
+		window: window,
+		times:  make([]time.Time, limit),
+		now:    time.Now,
+	}
+}
+
+func (l *Log) Allow() error {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.times) == 0 {
+		return ratelimit.ErrTriggerLimit
+	}
+	// times is a ring, next holds the oldest request
+	if oldest := l.times[l.next]; !oldest.IsZero() && now.Sub(oldest) < l.window {
+		return ratelimit.ErrTriggerLimit
+	}
+	l.times[l.next] = now
+	l.next = (l.next + 1) % len(l.times)
+	return nil
+}
+
+func (l *Log) Quota() ratelimit.Quota {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	q := ratelimit.Quota{Limit: int64(len(l.times))}
+	for i := range l.times {
+		// from the oldest request to the newest
+		t := l.times[(l.next+i)%len(l.times)]
+		if t.IsZero() || now.Sub(t) >= l.window {
+			q.Remaining++
+			continue
+		}
+		if q.RetryAfter == 0 {
+			q.RetryAfter = t.Add(l.window).Sub(now)
+		}
+		q.Reset = t.Add(l.window).Sub(now)
+	}
+	if q.Remaining > 0 {
+		q.RetryAfter = 0
+	}
+	return q
+}
@@ -0,0 +1,107 @@
+package slidingwindow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/middleware/ratelimit"
+)
+
+func TestLog(t *testing.T) {
+	l := NewLog(3, 100*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if err := l.Allow(); err != nil {
+			t.Fatalf("expected request %d to pass, got %v", i, err)
+		}
+	}
+	if err := l.Allow(); err != ratelimit.ErrTriggerLimit {
+		t.Fatalf("expected %v, got %v", ratelimit.ErrTriggerLimit, err)
+	}
+	time.Sleep(110 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if err := l.Allow(); err != nil {
+			t.Fatalf("expected request %d to pass after the window, got %v", i, err)
+		}
+	}
+	if err := l.Allow(); err != ratelimit.ErrTriggerLimit {
+		t.Fatalf("expected %v, got %v", ratelimit.ErrTriggerLimit, err)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	now := time.Unix(100, 0)
+	c := NewCounter(10, 100*time.Millisecond)
+	c.now = func() time.Time { return now }
+	c.start = now
+	var allowed int
+	for i := 0; i < 20; i++ {
+		if c.Allow() == nil {
+			allowed++
+		}
+	}
+	if allowed != 10 {
+		t.Fatalf("expected 10 requests to pass, got %d", allowed)
+	}
+
+	// 10ms into the next window the previous one still weighs 90%
+	now = now.Add(110 * time.Millisecond)
+	if err := c.Allow(); err != nil {
+		t.Fatalf("expected a request to pass, got %v", err)
+	}
+	if err := c.Allow(); err != ratelimit.ErrTriggerLimit {
+		t.Fatalf("expected %v, got %v", ratelimit.ErrTriggerLimit, err)
+	}
+
+	// 50ms in, half of the previous window is left
+	now = now.Add(40 * time.Millisecond)
+	allowed = 0
+	for i := 0; i < 20; i++ {
+		if c.Allow() == nil {
+			allowed++
+		}
+	}
+	if allowed != 4 {
+		t.Fatalf("expected 4 requests to pass, got %d", allowed)
+	}
+
+	now = now.Add(300 * time.Millisecond)
+	allowed = 0
+	for i := 0; i < 20; i++ {
+		if c.Allow() == nil {
+			allowed++
+		}
+	}
+	if allowed != 10 {
+		t.Fatalf("expected the counters to reset, got %d", allowed)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	now := time.Unix(100, 0)
+	c := NewCounter(10, 100*time.Millisecond)
+	c.now = func() time.Time { return now }
+	c.start = now
+	for i := 0; i < 10; i++ {
+		_ = c.Allow()
+	}
+	now = now.Add(105 * time.Millisecond)
+	_ = c.Allow()
+	// 9.5 weighted from the previous window and 1 in the current one, the weight has to fall below 90%
+	q := c.Quota()
+	q.RetryAfter = q.RetryAfter.Round(time.Millisecond)
+	want := ratelimit.Quota{Limit: 10, Remaining: 0, Reset: 195 * time.Millisecond, RetryAfter: 5 * time.Millisecond}
+	if q != want {
+		t.Fatalf("expected %+v, got %+v", want, q)
+	}
+
+	l := NewLog(2, 100*time.Millisecond)
+	l.now = func() time.Time { return now }
+	_ = l.Allow()
+	now = now.Add(30 * time.Millisecond)
+	_ = l.Allow()
+	q = l.Quota()
+	want = ratelimit.Quota{Limit: 2, Remaining: 0, Reset: 100 * time.Millisecond, RetryAfter: 70 * time.Millisecond}
+	if q != want {
+		t.Fatalf("expected %+v, got %+v", want, q)
+	}
+}
@@ -0,0 +1,151 @@
+package recovery
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"github.com/kanengo/goutil/pkg/log"
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/transport"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/peer"
+)
+
+// ErrUnknownRequest is returned for a recovered panic by the default handler.
+var ErrUnknownRequest = errors.InternalServer("unknown request error")
+
+// HandlerFunc converts the recovered panic value into the error returned to the caller.
+type HandlerFunc func(ctx context.Context, req, err any) error
+
+type Option func(*options)
+
+type options struct {
+	handler    HandlerFunc
+	counter    func(method string)
+	registerer prometheus.Registerer
+}
+
+// WithHandler with the handler converting the panic into an error.
+func WithHandler(h HandlerFunc) Option {
+	return func(o *options) {
+		o.handler = h
+	}
+}
+
+// WithCounter with a func called with the method of every recovered panic instead of
+// the default ngrpc_panics_recovered_total counter.
+func WithCounter(fn func(method string)) Option {
+	return func(o *options) {
+		o.counter = fn
+	}
+}
+
+// WithRegisterer with the registerer of the default counter, defaults to prometheus.DefaultRegisterer.
+func WithRegisterer(r prometheus.Registerer) Option {
+	return func(o *options) {
+		o.registerer = r
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		handler: func(ctx context.Context, req, err any) error {
+			return ErrUnknownRequest
+		},
+		registerer: prometheus.DefaultRegisterer,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.counter == nil {
+		panics := newCounter(o.registerer)
+		o.counter = func(method string) { panics.WithLabelValues(method).Inc() }
+	}
+	return o
+}
+
+// newCounter registers the counter of the recovered panics by method,
+// the middleware created with the same registerer share it.
+func newCounter(r prometheus.Registerer) *prometheus.CounterVec {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ngrpc",
+		Name:      "panics_recovered_total",
+		Help:      "The total number of panics recovered by the recovery middleware.",
+	}, []string{"method"})
+	if err := r.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if stderrors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(*prometheus.CounterVec); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+// Recovery recovers the panics of unary handlers and of whole server streams.
+func Recovery(opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (reply any, err error) {
+			defer func() {
+				if rerr := recover(); rerr != nil {
+					err = o.recovered(ctx, req, rerr)
+				}
+			}()
+			return handler(ctx, req)
+		}
+	}
+}
+
+// StreamRecovery recovers the panics of the per-message stream middleware.
+func StreamRecovery(opts ...Option) middleware.StreamMiddleware {
+	o := newOptions(opts)
+	return func(handler middleware.StreamHandler) middleware.StreamHandler {
+		return func(ctx context.Context, dir middleware.StreamDirection, msg any) (err error) {
+			defer func() {
+				if rerr := recover(); rerr != nil {
+					err = o.recovered(ctx, msg, rerr)
+				}
+			}()
+			return handler(ctx, dir, msg)
+		}
+	}
+}
+
+func (o *options) recovered(ctx context.Context, req, rerr any) error {
+	var method string
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		method = tr.FullMethod()
+	}
+	o.counter(method)
+
+	buf := make([]byte, 64<<10)
+	buf = buf[:runtime.Stack(buf, false)]
+	log.Error("[recovery] panic recovered",
+		zap.String("method", method),
+		zap.String("peer", peerAddr(ctx)),
+		zap.String("panic", fmt.Sprint(rerr)),
+		zap.ByteString("stack", buf),
+	)
+
+	return o.handler(ctx, req, rerr)
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		if r, ok := tr.(interface{ Request() *http.Request }); ok && r.Request() != nil {
+			return r.Request().RemoteAddr
+		}
+	}
+	return ""
+}
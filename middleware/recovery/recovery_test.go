@@ -0,0 +1,68 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecovery(t *testing.T) {
+	var count int
+	h := Recovery(WithCounter(func(string) { count++ }))(func(ctx context.Context, req any) (any, error) {
+		panic("panic")
+	})
+	_, err := h(context.Background(), "req")
+	if !errors.IsInternalServer(err) {
+		t.Errorf("expect internal server error, got %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expect %d, got %d", 1, count)
+	}
+}
+
+func TestRecoveryDefaultCounter(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	panics := func(handler middleware.Handler) {
+		_, _ = Recovery(WithRegisterer(reg))(handler)(context.Background(), "req")
+	}
+	panics(func(ctx context.Context, req any) (any, error) { panic("panic") })
+	panics(func(ctx context.Context, req any) (any, error) { panic("panic") })
+	panics(func(ctx context.Context, req any) (any, error) { return req, nil })
+
+	if got := testutil.ToFloat64(newCounter(reg).WithLabelValues("")); got != 2 {
+		t.Errorf("expect %d, got %v", 2, got)
+	}
+}
+
+func TestRecoveryHandler(t *testing.T) {
+	h := Recovery(WithHandler(func(ctx context.Context, req, err any) error {
+		return errors.InternalServer(fmt.Sprint(err)).WithReason("PANIC")
+	}))(func(ctx context.Context, req any) (any, error) {
+		panic("boom")
+	})
+	_, err := h(context.Background(), "req")
+	if e := errors.FromError(err); e.Message != "boom" || e.Reason != "PANIC" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	reply, err := Recovery()(func(ctx context.Context, req any) (any, error) {
+		return req, nil
+	})(context.Background(), "ok")
+	if err != nil || reply != "ok" {
+		t.Errorf("expect %v, got %v %v", "ok", reply, err)
+	}
+}
+
+func TestStreamRecovery(t *testing.T) {
+	h := StreamRecovery()(func(ctx context.Context, dir middleware.StreamDirection, msg any) error {
+		panic("panic")
+	})
+	if err := h(context.Background(), middleware.StreamRecv, "msg"); !errors.IsInternalServer(err) {
+		t.Errorf("expect internal server error, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+)
+
+// Stream is the message stream shared by transports that support streaming.
+// Middleware wrapping a whole server stream receive it as the request.
+type Stream interface {
+	Context() context.Context
+	SendMsg(m any) error
+	RecvMsg(m any) error
+}
+
+type StreamDirection uint8
+
+const (
+	StreamSend StreamDirection = iota + 1
+	StreamRecv
+)
+
+func (d StreamDirection) String() string {
+	switch d {
+	case StreamSend:
+		return "send"
+	case StreamRecv:
+		return "recv"
+	}
+	return "unknown"
+}
+
+// StreamHandler handles a single message sent or received on a stream.
+type StreamHandler func(ctx context.Context, dir StreamDirection, msg any) error
+
+type StreamMiddleware func(StreamHandler) StreamHandler
+
+func ChainStream(ms ...StreamMiddleware) StreamMiddleware {
+	return func(next StreamHandler) StreamHandler {
+		for i := 0; i < len(ms); i++ {
+			next = ms[i](next)
+		}
+		return next
+	}
+}
@@ -0,0 +1,131 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/selector"
+	"github.com/kanengo/ngrpc/transport"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/kanengo/ngrpc/middleware/tracing"
+
+type Option func(*options)
+
+type options struct {
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// WithTracerProvider with the tracer provider, defaults to the global one.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithPropagator with the propagator, defaults to W3C trace context and baggage.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(o *options) {
+		o.propagator = p
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		tracerProvider: otel.GetTracerProvider(),
+		propagator:     propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Server starts a server span from the trace context of the request header
+// and injects the span context into the reply header.
+func Server(opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	tracer := o.tracerProvider.Tracer(instrumentationName)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			ctx = o.propagator.Extract(ctx, tr.RequestHeader())
+			ctx, span := tracer.Start(ctx, tr.FullMethod(),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attributes(tr)...),
+			)
+			defer span.End()
+			if tr.ReplyHeader() != nil {
+				o.propagator.Inject(ctx, tr.ReplyHeader())
+			}
+
+			reply, err := handler(ctx, req)
+			setStatus(span, err)
+			return reply, err
+		}
+	}
+}
+
+// Client starts a client span, injects its context into the request header
+// and tags the address of the node selected for the call.
+func Client(opts ...Option) middleware.Middleware {
+	o := newOptions(opts)
+	tracer := o.tracerProvider.Tracer(instrumentationName)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			ctx, span := tracer.Start(ctx, tr.FullMethod(),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attributes(tr)...),
+			)
+			defer span.End()
+			o.propagator.Inject(ctx, tr.RequestHeader())
+
+			peer, ok := selector.FromPeerContext(ctx)
+			if !ok {
+				peer = &selector.Peer{}
+				ctx = selector.NewPeerContext(ctx, peer)
+			}
+			reply, err := handler(ctx, req)
+			if peer.Node != nil {
+				span.SetAttributes(attribute.String("net.peer.name", peer.Node.Address()))
+			}
+			setStatus(span, err)
+			return reply, err
+		}
+	}
+}
+
+func attributes(tr transport.Transporter) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("rpc.system", tr.Kind().String()),
+		attribute.String("rpc.method", tr.FullMethod()),
+		attribute.String("endpoint", tr.Endpoint()),
+	}
+}
+
+// setStatus records the errors.Error code of err on the span.
+func setStatus(span trace.Span, err error) {
+	code := errors.Code(err)
+	span.SetAttributes(attribute.Int("rpc.code", int(code)))
+	if err == nil {
+		span.SetStatus(codes.Ok, "")
+		return
+	}
+	span.RecordError(err)
+	span.SetAttributes(attribute.String("rpc.reason", errors.Reason(err)))
+	span.SetStatus(codes.Error, err.Error())
+}
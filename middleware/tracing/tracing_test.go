@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/selector"
+	"github.com/kanengo/ngrpc/transport"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type headerCarrier map[string]string
+
+func (hc headerCarrier) Get(key string) string { return hc[key] }
+
+func (hc headerCarrier) Set(key, value string) { hc[key] = value }
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type testTransport struct {
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (tr *testTransport) Endpoint() string                { return "discovery:///helloworld" }
+func (tr *testTransport) FullMethod() string              { return "/helloworld.Greeter/SayHello" }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *testTransport) ReplyHeader() transport.Header   { return tr.replyHeader }
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	clientTr := &testTransport{reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+	serverTr := &testTransport{reqHeader: clientTr.reqHeader, replyHeader: headerCarrier{}}
+
+	server := Server(WithTracerProvider(tp))(func(ctx context.Context, req any) (any, error) {
+		return nil, errors.NotFound("user not found").WithReason("USER_NOT_FOUND")
+	})
+	client := Client(WithTracerProvider(tp))(func(ctx context.Context, req any) (any, error) {
+		if peer, ok := selector.FromPeerContext(ctx); ok {
+			peer.Node = selector.NewNode("grpc", "127.0.0.1:9000", nil)
+		}
+		return server(transport.NewServerContext(context.Background(), serverTr), req)
+	})
+
+	if _, err := client(transport.NewClientContext(context.Background(), clientTr), "hello"); !errors.IsNotFound(err) {
+		t.Fatalf("expect not found, got %v", err)
+	}
+	if clientTr.reqHeader.Get("traceparent") == "" {
+		t.Error("expect traceparent injected into the request header")
+	}
+	if serverTr.replyHeader.Get("traceparent") == "" {
+		t.Error("expect traceparent injected into the reply header")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expect %d spans, got %d", 2, len(spans))
+	}
+	serverSpan, clientSpan := spans[0], spans[1]
+	if serverSpan.SpanKind != trace.SpanKindServer || clientSpan.SpanKind != trace.SpanKindClient {
+		t.Errorf("unexpected span kinds %v %v", serverSpan.SpanKind, clientSpan.SpanKind)
+	}
+	if serverSpan.Parent.SpanID() != clientSpan.SpanContext.SpanID() {
+		t.Error("expect the server span to be a child of the client span")
+	}
+	for _, span := range spans {
+		if span.Name != "/helloworld.Greeter/SayHello" || span.Status.Code != codes.Error {
+			t.Errorf("unexpected span %s %v", span.Name, span.Status)
+		}
+		if !hasAttribute(span.Attributes, attribute.Int("rpc.code", 404)) {
+			t.Errorf("expect rpc.code 404, got %v", span.Attributes)
+		}
+	}
+	if !hasAttribute(clientSpan.Attributes, attribute.String("net.peer.name", "127.0.0.1:9000")) {
+		t.Errorf("expect the peer address, got %v", clientSpan.Attributes)
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, kv attribute.KeyValue) bool {
+	for _, a := range attrs {
+		if a == kv {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package validate
+
+import (
+	"context"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+)
+
+// ReasonValidator is the reason of the errors returned for invalid requests.
+const ReasonValidator = "VALIDATOR"
+
+type validator interface {
+	Validate() error
+}
+
+type validatorAll interface {
+	ValidateAll() error
+}
+
+// fieldError is implemented by the validation errors generated by protoc-gen-validate.
+type fieldError interface {
+	Field() string
+	Reason() string
+}
+
+// causer is implemented by the validation errors of embedded messages.
+type causer interface {
+	Cause() error
+}
+
+// multiError is implemented by the errors returned from ValidateAll.
+type multiError interface {
+	AllErrors() []error
+}
+
+// Validator rejects requests failing their generated validation with errors.BadRequest,
+// the metadata of the error maps each invalid field to the violation.
+func Validator() middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			if err := validate(req); err != nil {
+				return nil, errors.BadRequest(err.Error()).
+					WithReason(ReasonValidator).
+					WithMetadata(violations(err)).
+					WithCause(err)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+func validate(req any) error {
+	switch v := req.(type) {
+	case validatorAll:
+		return v.ValidateAll()
+	case validator:
+		return v.Validate()
+	}
+	return nil
+}
+
+func violations(err error) map[string]string {
+	md := make(map[string]string)
+	collect(md, "", err)
+	return md
+}
+
+// collect adds the violations of err to md, the fields of embedded messages
+// are named by their dotted path, e.g. "address.city".
+func collect(md map[string]string, prefix string, err error) {
+	errs := []error{err}
+	if me, ok := err.(multiError); ok {
+		errs = me.AllErrors()
+	}
+	for _, e := range errs {
+		fe, ok := e.(fieldError)
+		if !ok {
+			continue
+		}
+		field := fe.Field()
+		if prefix != "" {
+			field = prefix + "." + field
+		}
+		if c, ok := e.(causer); ok && c.Cause() != nil {
+			collect(md, field, c.Cause())
+			continue
+		}
+		md[field] = fe.Reason()
+	}
+}
@@ -0,0 +1,139 @@
+package validate
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kanengo/ngrpc/errors"
+)
+
+type fieldErr struct {
+	field, reason string
+}
+
+func (e fieldErr) Field() string  { return e.field }
+func (e fieldErr) Reason() string { return e.reason }
+func (e fieldErr) Error() string  { return "invalid " + e.field + ": " + e.reason }
+
+type nestedErr struct {
+	fieldErr
+	cause error
+}
+
+func (e nestedErr) Cause() error { return e.cause }
+
+type multiErr []error
+
+func (m multiErr) AllErrors() []error { return m }
+func (m multiErr) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, e := range m {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+type helloRequest struct {
+	name string
+	age  int
+}
+
+func (r *helloRequest) Validate() error {
+	if r.name == "" {
+		return fieldErr{"name", "value length must be at least 1 runes"}
+	}
+	return nil
+}
+
+func (r *helloRequest) ValidateAll() error {
+	var errs multiErr
+	if r.name == "" {
+		errs = append(errs, fieldErr{"name", "value length must be at least 1 runes"})
+	}
+	if r.age < 0 {
+		errs = append(errs, fieldErr{"age", "value must be greater than or equal to 0"})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type address struct{ city string }
+
+func (a *address) ValidateAll() error {
+	if a.city == "" {
+		return multiErr{fieldErr{"city", "value length must be at least 1 runes"}}
+	}
+	return nil
+}
+
+type userRequest struct {
+	name    string
+	address *address
+}
+
+func (r *userRequest) ValidateAll() error {
+	var errs multiErr
+	if r.name == "" {
+		errs = append(errs, fieldErr{"name", "value length must be at least 1 runes"})
+	}
+	if err := r.address.ValidateAll(); err != nil {
+		errs = append(errs, nestedErr{fieldErr{"address", "embedded message failed validation"}, err})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type legacyRequest struct{ name string }
+
+func (r *legacyRequest) Validate() error {
+	if r.name == "" {
+		return fieldErr{"name", "required"}
+	}
+	return nil
+}
+
+func TestValidator(t *testing.T) {
+	h := Validator()(func(ctx context.Context, req any) (any, error) {
+		return "reply", nil
+	})
+	tests := []struct {
+		name string
+		req  any
+		want map[string]string
+	}{
+		{"valid", &helloRequest{name: "kratos"}, nil},
+		{"all", &helloRequest{age: -1}, map[string]string{
+			"name": "value length must be at least 1 runes",
+			"age":  "value must be greater than or equal to 0",
+		}},
+		{"nested", &userRequest{address: &address{}}, map[string]string{
+			"name":         "value length must be at least 1 runes",
+			"address.city": "value length must be at least 1 runes",
+		}},
+		{"validate", &legacyRequest{}, map[string]string{"name": "required"}},
+		{"plain", "hello", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reply, err := h(context.Background(), test.req)
+			if test.want == nil {
+				if err != nil || reply != "reply" {
+					t.Errorf("expect reply, got %v %v", reply, err)
+				}
+				return
+			}
+			if !errors.IsBadRequest(err) || errors.Reason(err) != ReasonValidator {
+				t.Fatalf("expect bad request, got %v", err)
+			}
+			if md := errors.FromError(err).Metadata; !reflect.DeepEqual(md, test.want) {
+				t.Errorf("expect %v, got %v", test.want, md)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package ngrpc
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/kanengo/ngrpc/registry"
+	"github.com/kanengo/ngrpc/transport"
+)
+
+type Option func(o *options)
+
+type options struct {
+	id        string
+	name      string
+	version   string
+	metadata  map[string]string
+	endpoints []*url.URL
+
+	ctx  context.Context
+	sigs []os.Signal
+
+	registrar        registry.Registrar
+	registrarTimeout time.Duration
+	stopTimeout      time.Duration
+	drainTimeout     time.Duration
+	servers          []transport.Server
+
+	beforeStart []func(context.Context) error
+	beforeStop  []func(context.Context) error
+	afterStart  []func(context.Context) error
+	afterStop   []func(context.Context) error
+}
+
+// ID with the service instance id.
+func ID(id string) Option {
+	return func(o *options) { o.id = id }
+}
+
+// Name with the service name.
+func Name(name string) Option {
+	return func(o *options) { o.name = name }
+}
+
+// Version with the service version.
+func Version(version string) Option {
+	return func(o *options) { o.version = version }
+}
+
+// Metadata with the service instance metadata.
+func Metadata(md map[string]string) Option {
+	return func(o *options) { o.metadata = md }
+}
+
+// Endpoint overrides the endpoints registered instead of those of the servers.
+func Endpoint(endpoints ...*url.URL) Option {
+	return func(o *options) { o.endpoints = endpoints }
+}
+
+// Context with the parent context of the app.
+func Context(ctx context.Context) Option {
+	return func(o *options) { o.ctx = ctx }
+}
+
+// Signal with the os signals stopping the app, defaults to SIGTERM, SIGQUIT and SIGINT.
+func Signal(sigs ...os.Signal) Option {
+	return func(o *options) { o.sigs = sigs }
+}
+
+func Registrar(r registry.Registrar) Option {
+	return func(o *options) { o.registrar = r }
+}
+
+func RegistrarTimeout(t time.Duration) Option {
+	return func(o *options) { o.registrarTimeout = t }
+}
+
+// StopTimeout with the deadline of stopping the servers.
+func StopTimeout(t time.Duration) Option {
+	return func(o *options) { o.stopTimeout = t }
+}
+
+// DrainTimeout with the wait between deregistering and stopping the servers,
+// so that clients can notice the instance is gone before connections are closed.
+func DrainTimeout(t time.Duration) Option {
+	return func(o *options) { o.drainTimeout = t }
+}
+
+func Server(srv ...transport.Server) Option {
+	return func(o *options) { o.servers = srv }
+}
+
+// BeforeStart runs fn before the servers are started.
+func BeforeStart(fn func(context.Context) error) Option {
+	return func(o *options) { o.beforeStart = append(o.beforeStart, fn) }
+}
+
+// AfterStart runs fn after the servers are started and registered.
+func AfterStart(fn func(context.Context) error) Option {
+	return func(o *options) { o.afterStart = append(o.afterStart, fn) }
+}
+
+// BeforeStop runs fn before the instance is deregistered.
+func BeforeStop(fn func(context.Context) error) Option {
+	return func(o *options) { o.beforeStop = append(o.beforeStop, fn) }
+}
+
+// AfterStop runs fn after the servers are stopped.
+func AfterStop(fn func(context.Context) error) Option {
+	return func(o *options) { o.afterStop = append(o.afterStop, fn) }
+}
@@ -0,0 +1,21 @@
+package selector
+
+import (
+	"context"
+)
+
+type peerKey struct{}
+
+// Peer holds the node selected for a call, the transports fill it when the context carries one.
+type Peer struct {
+	Node Node
+}
+
+func NewPeerContext(ctx context.Context, p *Peer) context.Context {
+	return context.WithValue(ctx, peerKey{}, p)
+}
+
+func FromPeerContext(ctx context.Context) (p *Peer, ok bool) {
+	p, ok = ctx.Value(peerKey{}).(*Peer)
+	return
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/kanengo/ngrpc/registry"
 	"github.com/kanengo/ngrpc/selector"
 	"github.com/kanengo/ngrpc/selector/balancer/p2c"
+	"github.com/kanengo/ngrpc/transport"
 	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/balancer/base"
 	"google.golang.org/grpc/metadata"
@@ -57,10 +58,21 @@ type balancerPicker struct {
 }
 
 func (p balancerPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
-	node, done, err := p.selector.Select(info.Ctx)
+	var opts []selector.SelectOption
+	if tr, ok := transport.FromClientContext(info.Ctx); ok {
+		if gtr, ok := tr.(*Transport); ok && len(gtr.NodeFilters()) > 0 {
+			opts = append(opts, func(options *selector.SelectOptions) {
+				options.NodeFilters = gtr.NodeFilters()
+			})
+		}
+	}
+	node, done, err := p.selector.Select(info.Ctx, opts...)
 	if err != nil {
 		return balancer.PickResult{}, err
 	}
+	if peer, ok := selector.FromPeerContext(info.Ctx); ok {
+		peer.Node = node
+	}
 
 	return balancer.PickResult{
 		SubConn: node.(*grpcNode).subConn,
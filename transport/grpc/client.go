@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/kanengo/goutil/pkg/matcher"
+	"github.com/kanengo/ngrpc/errors"
 	"github.com/kanengo/ngrpc/middleware"
 	"github.com/kanengo/ngrpc/registry"
 	"github.com/kanengo/ngrpc/selector"
@@ -42,7 +44,29 @@ func WithTimeout(timeout time.Duration) ClientOption {
 
 func WithMiddleware(ms ...middleware.Middleware) ClientOption {
 	return func(options *clientOptions) {
-		options.middleware = ms
+		options.middleware.Use(ms...)
+	}
+}
+
+// WithMethodMiddleware with middleware for the methods matched by selector,
+// e.g. exact "/helloworld.Greeter/SayHello", service prefix "/helloworld.Greeter/*" or wildcard "/*".
+func WithMethodMiddleware(selector string, ms ...middleware.Middleware) ClientOption {
+	return func(options *clientOptions) {
+		options.middleware.Add(selector, ms...)
+	}
+}
+
+// WithStreamTimeout with the deadline of a whole stream, zero means no deadline.
+func WithStreamTimeout(timeout time.Duration) ClientOption {
+	return func(options *clientOptions) {
+		options.streamTimeout = timeout
+	}
+}
+
+// WithStreamMiddleware with per-message middleware for streaming calls.
+func WithStreamMiddleware(ms ...middleware.StreamMiddleware) ClientOption {
+	return func(options *clientOptions) {
+		options.streamMiddleware.Use(ms...)
 	}
 }
 
@@ -58,6 +82,12 @@ func WithUnaryInterceptor(in ...grpc.UnaryClientInterceptor) ClientOption {
 	}
 }
 
+func WithStreamInterceptor(in ...grpc.StreamClientInterceptor) ClientOption {
+	return func(options *clientOptions) {
+		options.streamInts = in
+	}
+}
+
 func WithOptions(opts ...grpc.DialOption) ClientOption {
 	return func(options *clientOptions) {
 		options.grpcOpts = opts
@@ -71,15 +101,18 @@ func WithNodeFilters(nodeFilters ...selector.Filter[selector.Node]) ClientOption
 }
 
 type clientOptions struct {
-	endpoint     string
-	tlsConf      *tls.Config
-	timeout      time.Duration
-	middleware   []middleware.Middleware
-	ints         []grpc.UnaryClientInterceptor
-	grpcOpts     []grpc.DialOption
-	discovery    registry.Discovery
-	balancerName string
-	nodeFilters  []selector.Filter[selector.Node]
+	endpoint         string
+	tlsConf          *tls.Config
+	timeout          time.Duration
+	streamTimeout    time.Duration
+	middleware       matcher.Matcher[middleware.Middleware]
+	streamMiddleware matcher.Matcher[middleware.StreamMiddleware]
+	ints             []grpc.UnaryClientInterceptor
+	streamInts       []grpc.StreamClientInterceptor
+	grpcOpts         []grpc.DialOption
+	discovery        registry.Discovery
+	balancerName     string
+	nodeFilters      []selector.Filter[selector.Node]
 }
 
 func Dial(ctx context.Context, opts ...ClientOption) (*grpc.ClientConn, error) {
@@ -92,8 +125,10 @@ func DialInsecure(ctx context.Context, opts ...ClientOption) (*grpc.ClientConn,
 
 func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.ClientConn, error) {
 	options := clientOptions{
-		timeout:      2 * time.Second,
-		balancerName: balancerName,
+		timeout:          2 * time.Second,
+		middleware:       matcher.New[middleware.Middleware](),
+		streamMiddleware: matcher.New[middleware.StreamMiddleware](),
+		balancerName:     balancerName,
 	}
 	for _, o := range opts {
 		o(&options)
@@ -107,9 +142,18 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 		ints = append(ints, options.ints...)
 	}
 
+	streamInts := []grpc.StreamClientInterceptor{
+		streamClientInterceptor(options.middleware, options.streamMiddleware, options.streamTimeout, options.nodeFilters),
+	}
+
+	if len(options.streamInts) > 0 {
+		streamInts = append(streamInts, options.streamInts...)
+	}
+
 	grpcOpts := []grpc.DialOption{
 		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{"%s":{}}]}`, options.balancerName)),
 		grpc.WithChainUnaryInterceptor(ints...),
+		grpc.WithChainStreamInterceptor(streamInts...),
 	}
 
 	if options.discovery != nil {
@@ -131,7 +175,7 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 	return grpc.DialContext(ctx, options.endpoint, grpcOpts...)
 }
 
-func unaryClientInterceptor(ms []middleware.Middleware, timeout time.Duration, nodeFilters []selector.Filter[selector.Node]) grpc.UnaryClientInterceptor {
+func unaryClientInterceptor(ms matcher.Matcher[middleware.Middleware], timeout time.Duration, nodeFilters []selector.Filter[selector.Node]) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		ctx = transport.NewClientContext(ctx, &Transport{
 			endpoint:    cc.Target(),
@@ -147,20 +191,14 @@ func unaryClientInterceptor(ms []middleware.Middleware, timeout time.Duration, n
 		}
 
 		h := func(ctx context.Context, req any) (any, error) {
-			if tr, ok := transport.FromClientContext(ctx); ok {
-				header := tr.RequestHeader()
-				keys := header.Keys()
-				keyValues := make([]string, 0, len(keys)*2)
-				for _, k := range keys {
-					keyValues = append(keyValues, k, header.Get(k))
-				}
-				ctx = grpcmd.AppendToOutgoingContext(ctx, keyValues...)
+			if err := invoker(outgoingContext(ctx), method, req, reply, cc, opts...); err != nil {
+				return nil, errors.FromError(err)
 			}
-			return reply, invoker(ctx, method, req, reply, cc, opts...)
+			return reply, nil
 		}
 
-		if len(ms) > 0 {
-			h = middleware.Chain(ms...)(h)
+		if next := ms.Match(method); len(next) > 0 {
+			h = middleware.Chain(next...)(h)
 		}
 
 		_, err := h(ctx, req)
@@ -168,3 +206,67 @@ func unaryClientInterceptor(ms []middleware.Middleware, timeout time.Duration, n
 		return err
 	}
 }
+
+func streamClientInterceptor(ms matcher.Matcher[middleware.Middleware], streamMs matcher.Matcher[middleware.StreamMiddleware], timeout time.Duration, nodeFilters []selector.Filter[selector.Node]) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = transport.NewClientContext(ctx, &Transport{
+			endpoint:    cc.Target(),
+			fullMethod:  method,
+			reqHeader:   headerCarrier{},
+			replyHeader: nil,
+			nodeFilters: nodeFilters,
+		})
+		var cancel context.CancelFunc
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+		}
+
+		var cs grpc.ClientStream
+		h := func(ctx context.Context, req any) (any, error) {
+			var err error
+			cs, err = streamer(outgoingContext(ctx), desc, cc, method, opts...)
+			if err != nil {
+				return nil, errors.FromError(err)
+			}
+			return cs, nil
+		}
+
+		if next := ms.Match(method); len(next) > 0 {
+			h = middleware.Chain(next...)(h)
+		}
+
+		if _, err := h(ctx, nil); err != nil {
+			if cancel != nil {
+				cancel()
+			}
+			return nil, err
+		}
+
+		ws := &wrappedClientStream{
+			ClientStream: cs,
+			desc:         desc,
+			cancel:       cancel,
+		}
+		ws.msgHandler = ws.handleMsg
+		if next := streamMs.Match(method); len(next) > 0 {
+			ws.msgHandler = middleware.ChainStream(next...)(ws.msgHandler)
+		}
+
+		return ws, nil
+	}
+}
+
+// outgoingContext appends the request header set by middleware to the outgoing grpc metadata.
+func outgoingContext(ctx context.Context) context.Context {
+	tr, ok := transport.FromClientContext(ctx)
+	if !ok {
+		return ctx
+	}
+	header := tr.RequestHeader()
+	keys := header.Keys()
+	keyValues := make([]string, 0, len(keys)*2)
+	for _, k := range keys {
+		keyValues = append(keyValues, k, header.Get(k))
+	}
+	return grpcmd.AppendToOutgoingContext(ctx, keyValues...)
+}
@@ -2,7 +2,9 @@ package grpc
 
 import (
 	"context"
+	stderrors "errors"
 
+	"github.com/kanengo/ngrpc/errors"
 	"github.com/kanengo/ngrpc/middleware"
 	"github.com/kanengo/ngrpc/transport"
 	"google.golang.org/grpc"
@@ -16,7 +18,7 @@ func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 		replyHeader := grpcmd.MD{}
 		tr := &Transport{
 			endpoint:    "",
-			fullMethod:  "",
+			fullMethod:  info.FullMethod,
 			reqHeader:   headerCarrier(md),
 			replyHeader: headerCarrier(replyHeader),
 		}
@@ -42,6 +44,62 @@ func (s *Server) unaryServerInterceptor() grpc.UnaryServerInterceptor {
 			_ = grpc.SetHeader(ctx, replyHeader)
 		}
 
-		return
+		return resp, toGrpcError(err)
 	}
 }
+
+func (s *Server) streamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		md, _ := grpcmd.FromIncomingContext(ctx)
+		replyHeader := grpcmd.MD{}
+		tr := &Transport{
+			endpoint:    "",
+			fullMethod:  info.FullMethod,
+			reqHeader:   headerCarrier(md),
+			replyHeader: headerCarrier(replyHeader),
+		}
+
+		if s.endpoint != nil {
+			tr.endpoint = s.endpoint.String()
+		}
+		ctx = transport.NewServerContext(ctx, tr)
+		if s.streamTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.streamTimeout)
+			defer cancel()
+		}
+
+		ws := &wrappedServerStream{
+			ServerStream: ss,
+			ctx:          ctx,
+			replyHeader:  replyHeader,
+		}
+		ws.msgHandler = ws.handleMsg
+		if ms := s.streamMiddleware.Match(tr.FullMethod()); len(ms) > 0 {
+			ws.msgHandler = middleware.ChainStream(ms...)(ws.msgHandler)
+		}
+
+		h := func(ctx context.Context, req any) (any, error) {
+			ws.ctx = ctx
+			return nil, handler(srv, ws)
+		}
+		if ms := s.middleware.Match(tr.FullMethod()); len(ms) > 0 {
+			h = middleware.Chain(ms...)(h)
+		}
+
+		_, err := h(ctx, ws)
+
+		ws.sendHeader()
+
+		return toGrpcError(err)
+	}
+}
+
+// toGrpcError converts errors.Error into a grpc status error carrying its code, message and metadata.
+func toGrpcError(err error) error {
+	if se := new(errors.Error); stderrors.As(err, &se) {
+		return se.ToGrpcStatus().Err()
+	}
+	return err
+}
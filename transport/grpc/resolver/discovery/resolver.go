@@ -80,7 +80,8 @@ func (r *discoveryResolver) update(ins []*registry.ServiceInstance) {
 			ServerName: in.Name,
 			Attributes: parseAttributes(in.Metadata),
 		}
-		addr.Attributes.WithValue("__ServiceInstance__", in)
+		addr.Attributes = addr.Attributes.WithValue("__ServiceInstance__", in)
+		addrs = append(addrs, addr)
 	}
 
 	if len(addrs) == 0 {
@@ -99,7 +100,11 @@ func (r *discoveryResolver) update(ins []*registry.ServiceInstance) {
 func parseAttributes(metadata map[string]string) *attributes.Attributes {
 	var attr *attributes.Attributes
 	for k, v := range metadata {
-		attr.WithValue(k, v)
+		if attr == nil {
+			attr = attributes.New(k, v)
+		} else {
+			attr = attr.WithValue(k, v)
+		}
 	}
 	return attr
 }
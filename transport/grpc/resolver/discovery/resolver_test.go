@@ -21,6 +21,16 @@ func (t *testClientConn) UpdateState(s resolver.State) error {
 	return nil
 }
 
+type recordClientConn struct {
+	resolver.ClientConn
+	states []resolver.State
+}
+
+func (r *recordClientConn) UpdateState(s resolver.State) error {
+	r.states = append(r.states, s)
+	return nil
+}
+
 type testWatch struct {
 	err error
 
@@ -108,6 +118,37 @@ func TestWatchContextCancel(t *testing.T) {
 	t.Log("watch goroutine exited after 2 second")
 }
 
+func TestUpdate(t *testing.T) {
+	cc := &recordClientConn{}
+	r := &discoveryResolver{cc: cc}
+	ins := &registry.ServiceInstance{
+		ID:        "1",
+		Name:      "helloworld",
+		Endpoints: []string{"http://127.0.0.1:8000", "grpc://127.0.0.1:9000"},
+		Metadata:  map[string]string{"zone": "a"},
+	}
+	r.update([]*registry.ServiceInstance{ins, {ID: "2", Endpoints: []string{"http://127.0.0.1:8001"}}})
+
+	if len(cc.states) != 1 || len(cc.states[0].Addresses) != 1 {
+		t.Fatalf("expect one address published, got %v", cc.states)
+	}
+	addr := cc.states[0].Addresses[0]
+	if addr.Addr != "127.0.0.1:9000" || addr.ServerName != "helloworld" {
+		t.Errorf("unexpected address %v", addr)
+	}
+	if got := addr.Attributes.Value("__ServiceInstance__"); got != ins {
+		t.Errorf("expect the service instance attribute, got %v", got)
+	}
+	if got := addr.Attributes.Value("zone"); got != "a" {
+		t.Errorf("expect the metadata attribute, got %v", got)
+	}
+
+	r.update([]*registry.ServiceInstance{{ID: "3", Endpoints: []string{"http://127.0.0.1:8002"}}})
+	if len(cc.states) != 1 {
+		t.Errorf("expect no update without a grpc endpoint, got %v", cc.states)
+	}
+}
+
 func TestParseAttributes(t *testing.T) {
 	a := parseAttributes(map[string]string{
 		"a": "b",
@@ -57,6 +57,26 @@ func UnaryInterceptor(in ...grpc.UnaryServerInterceptor) ServerOption {
 	}
 }
 
+func StreamInterceptor(in ...grpc.StreamServerInterceptor) ServerOption {
+	return func(s *Server) {
+		s.streamInts = in
+	}
+}
+
+// StreamMiddleware with per-message middleware for streaming methods.
+func StreamMiddleware(m ...middleware.StreamMiddleware) ServerOption {
+	return func(s *Server) {
+		s.streamMiddleware.Use(m...)
+	}
+}
+
+// StreamTimeout with the deadline of a whole stream, zero means no deadline.
+func StreamTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.streamTimeout = timeout
+	}
+}
+
 func Options(opts ...grpc.ServerOption) ServerOption {
 	return func(s *Server) {
 		s.grpcOpts = opts
@@ -65,26 +85,31 @@ func Options(opts ...grpc.ServerOption) ServerOption {
 
 type Server struct {
 	*grpc.Server
-	baseCtx    context.Context
-	tlsConf    *tls.Config
-	address    string
-	endpoint   *url.URL
-	timeout    time.Duration
-	middleware matcher.Matcher[middleware.Middleware]
-	unaryInts  []grpc.UnaryServerInterceptor
-	grpcOpts   []grpc.ServerOption
-	health     *health.Server
-	lis        net.Listener
+	baseCtx          context.Context
+	tlsConf          *tls.Config
+	address          string
+	endpoint         *url.URL
+	timeout          time.Duration
+	streamTimeout    time.Duration
+	middleware       matcher.Matcher[middleware.Middleware]
+	streamMiddleware matcher.Matcher[middleware.StreamMiddleware]
+	unaryInts        []grpc.UnaryServerInterceptor
+	streamInts       []grpc.StreamServerInterceptor
+	grpcOpts         []grpc.ServerOption
+	health           *health.Server
+	lis              net.Listener
 }
 
 func NewServer(opts ...ServerOption) *Server {
 	srv := &Server{
-		baseCtx:    context.Background(),
-		timeout:    3 * time.Second,
-		middleware: matcher.New[middleware.Middleware](),
-		unaryInts:  nil,
-		grpcOpts:   nil,
-		health:     health.NewServer(),
+		baseCtx:          context.Background(),
+		timeout:          3 * time.Second,
+		middleware:       matcher.New[middleware.Middleware](),
+		streamMiddleware: matcher.New[middleware.StreamMiddleware](),
+		unaryInts:        nil,
+		streamInts:       nil,
+		grpcOpts:         nil,
+		health:           health.NewServer(),
 	}
 
 	for _, o := range opts {
@@ -99,8 +124,17 @@ func NewServer(opts ...ServerOption) *Server {
 		unaryInts = append(unaryInts, srv.unaryInts...)
 	}
 
+	streamInts := []grpc.StreamServerInterceptor{
+		srv.streamServerInterceptor(),
+	}
+
+	if len(srv.streamInts) > 0 {
+		streamInts = append(streamInts, srv.streamInts...)
+	}
+
 	grpcOpts := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(unaryInts...),
+		grpc.ChainStreamInterceptor(streamInts...),
 	}
 
 	if srv.tlsConf != nil {
@@ -126,6 +160,11 @@ func (s *Server) AddMiddleware(selector string, ms ...middleware.Middleware) {
 	s.middleware.Add(selector, ms...)
 }
 
+// AddStreamMiddleware add per-message middleware for the streaming methods matched by selector.
+func (s *Server) AddStreamMiddleware(selector string, ms ...middleware.StreamMiddleware) {
+	s.streamMiddleware.Add(selector, ms...)
+}
+
 func (s *Server) Endpoint() (*url.URL, error) {
 	if err := s.listenAndCheckEndpoint(); err != nil {
 		return nil, err
@@ -150,8 +189,20 @@ func (s *Server) Stop(ctx context.Context) error {
 	if s.health != nil {
 		s.health.Shutdown()
 	}
-	s.GracefulStop()
 	log.Info("[grpc] server stopping")
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	// long lived streams would hold GracefulStop past the deadline
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warn("[grpc] server couldn't stop gracefully in time, doing force stop")
+		s.Server.Stop()
+		<-done
+	}
 	return nil
 }
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/kanengo/ngrpc/middleware"
 	"github.com/kanengo/ngrpc/transport"
 	"google.golang.org/grpc"
+	grpcmd "google.golang.org/grpc/metadata"
 )
 
 type server struct {
@@ -62,7 +64,17 @@ type testKey struct{}
 func TestServer(t *testing.T) {
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, testKey{}, "test")
+	var streamMsgs int32
 	srv := NewServer(
+		StreamMiddleware(func(handler middleware.StreamHandler) middleware.StreamHandler {
+			return func(ctx context.Context, dir middleware.StreamDirection, msg any) error {
+				if tr, ok := transport.FromServerContext(ctx); !ok || tr.FullMethod() != "/helloworld.Greeter/SayHelloStream" {
+					t.Errorf("expect stream transport, got %v", tr)
+				}
+				atomic.AddInt32(&streamMsgs, 1)
+				return handler(ctx, dir, msg)
+			}
+		}),
 		Middleware(
 			func(handler middleware.Handler) middleware.Handler {
 				return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
@@ -79,6 +91,14 @@ func TestServer(t *testing.T) {
 		}),
 		Options(grpc.InitialConnWindowSize(0)),
 	)
+	srv.AddMiddleware("/helloworld.Greeter/SayHello", func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				tr.ReplyHeader().Set("full_method", tr.FullMethod())
+			}
+			return handler(ctx, req)
+		}
+	})
 	pb.RegisterGreeterServer(srv, &server{})
 
 	if e, err := srv.Endpoint(); err != nil || e == nil || strings.HasSuffix(e.Host, ":0") {
@@ -94,6 +114,9 @@ func TestServer(t *testing.T) {
 	time.Sleep(time.Second)
 	testClient(t, srv)
 	_ = srv.Stop(ctx)
+	if n := atomic.LoadInt32(&streamMsgs); n == 0 {
+		t.Errorf("expect stream middleware to be called, got %d", n)
+	}
 }
 
 func testClient(t *testing.T, srv *Server) {
@@ -101,6 +124,7 @@ func testClient(t *testing.T, srv *Server) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	var clientMethods int32
 	// new a gRPC client
 	conn, err := DialInsecure(context.Background(),
 		WithEndpoint(u.Host),
@@ -118,6 +142,12 @@ func testClient(t *testing.T, srv *Server) {
 				return handler(ctx, req)
 			}
 		}),
+		WithMethodMiddleware("/helloworld.Greeter/SayHello", func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+				atomic.AddInt32(&clientMethods, 1)
+				return handler(ctx, req)
+			}
+		}),
 	)
 	defer func() {
 		_ = conn.Close()
@@ -126,7 +156,8 @@ func testClient(t *testing.T, srv *Server) {
 		t.Fatal(err)
 	}
 	client := pb.NewGreeterClient(conn)
-	reply, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "kratos"})
+	var replyMD grpcmd.MD
+	reply, err := client.SayHello(context.Background(), &pb.HelloRequest{Name: "kratos"}, grpc.Header(&replyMD))
 	t.Log(err)
 	if err != nil {
 		t.Errorf("failed to call: %v", err)
@@ -134,6 +165,9 @@ func testClient(t *testing.T, srv *Server) {
 	if !reflect.DeepEqual(reply.Message, "Hello kratos") {
 		t.Errorf("expect %s, got %s", "Hello kratos", reply.Message)
 	}
+	if !reflect.DeepEqual(replyMD.Get("full_method"), []string{"/helloworld.Greeter/SayHello"}) {
+		t.Errorf("expect %v, got %v", []string{"/helloworld.Greeter/SayHello"}, replyMD.Get("full_method"))
+	}
 
 	streamCli, err := client.SayHelloStream(context.Background())
 	if err != nil {
@@ -156,6 +190,20 @@ func testClient(t *testing.T, srv *Server) {
 	if !reflect.DeepEqual(reply.Message, "hello cc") {
 		t.Errorf("expect %s, got %s", "hello cc", reply.Message)
 	}
+	header, err := streamCli.Header()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(header.Get("req_id"), []string{"3344"}) {
+		t.Errorf("expect %v, got %v", []string{"3344"}, header.Get("req_id"))
+	}
+	if len(header.Get("full_method")) != 0 {
+		t.Errorf("expect no method middleware on stream, got %v", header.Get("full_method"))
+	}
+	if n := atomic.LoadInt32(&clientMethods); n != 1 {
+		t.Errorf("expect %d, got %d", 1, n)
+	}
 }
 
 func TestTimeout(t *testing.T) {
@@ -192,21 +240,30 @@ func TestUnaryInterceptor(t *testing.T) {
 	}
 }
 
-//func TestStreamInterceptor(t *testing.T) {
-//	o := &Server{}
-//	v := []grpc.StreamServerInterceptor{
-//		func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-//			return nil
-//		},
-//		func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-//			return nil
-//		},
-//	}
-//	StreamInterceptor(v...)(o)
-//	if !reflect.DeepEqual(v, o.streamInts) {
-//		t.Errorf("expect %v, got %v", v, o.streamInts)
-//	}
-//}
+func TestStreamInterceptor(t *testing.T) {
+	o := &Server{}
+	v := []grpc.StreamServerInterceptor{
+		func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return nil
+		},
+		func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return nil
+		},
+	}
+	StreamInterceptor(v...)(o)
+	if !reflect.DeepEqual(v, o.streamInts) {
+		t.Errorf("expect %v, got %v", v, o.streamInts)
+	}
+}
+
+func TestStreamTimeout(t *testing.T) {
+	o := &Server{}
+	v := time.Duration(123)
+	StreamTimeout(v)(o)
+	if !reflect.DeepEqual(v, o.streamTimeout) {
+		t.Errorf("expect %s, got %s", v, o.streamTimeout)
+	}
+}
 
 func TestOptions(t *testing.T) {
 	o := &Server{}
@@ -218,3 +275,43 @@ func TestOptions(t *testing.T) {
 		t.Errorf("expect %v, got %v", v, o.grpcOpts)
 	}
 }
+
+func TestServerStopDeadline(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer()
+	pb.RegisterGreeterServer(srv, &server{})
+	go func() {
+		_ = srv.Start(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	e, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := DialInsecure(ctx, WithEndpoint(e.Host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// an open stream keeps GracefulStop waiting
+	stream, err := pb.NewGreeterClient(conn).SayHelloStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = stream.Send(&pb.HelloRequest{Name: "cc"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err = srv.Stop(stopCtx); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expect the stop to give up at the deadline, took %s", d)
+	}
+}
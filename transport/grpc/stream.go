@@ -0,0 +1,89 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"google.golang.org/grpc"
+	grpcmd "google.golang.org/grpc/metadata"
+)
+
+var (
+	_ middleware.Stream = (*wrappedServerStream)(nil)
+	_ middleware.Stream = (*wrappedClientStream)(nil)
+)
+
+// wrappedServerStream carries the transport context and runs the per-message middleware.
+type wrappedServerStream struct {
+	grpc.ServerStream
+	ctx         context.Context
+	replyHeader grpcmd.MD
+	headerOnce  sync.Once
+	msgHandler  middleware.StreamHandler
+}
+
+func (w *wrappedServerStream) Context() context.Context {
+	return w.ctx
+}
+
+func (w *wrappedServerStream) SendMsg(m interface{}) error {
+	return w.msgHandler(w.ctx, middleware.StreamSend, m)
+}
+
+func (w *wrappedServerStream) RecvMsg(m interface{}) error {
+	return w.msgHandler(w.ctx, middleware.StreamRecv, m)
+}
+
+func (w *wrappedServerStream) handleMsg(_ context.Context, dir middleware.StreamDirection, m any) error {
+	if dir == middleware.StreamSend {
+		w.sendHeader()
+		return w.ServerStream.SendMsg(m)
+	}
+	return w.ServerStream.RecvMsg(m)
+}
+
+// sendHeader flushes the reply header set by middleware, it must happen before the first message.
+func (w *wrappedServerStream) sendHeader() {
+	w.headerOnce.Do(func() {
+		if len(w.replyHeader) > 0 {
+			_ = w.ServerStream.SetHeader(w.replyHeader)
+		}
+	})
+}
+
+// wrappedClientStream runs the per-message middleware and releases the stream deadline once it ends.
+type wrappedClientStream struct {
+	grpc.ClientStream
+	desc       *grpc.StreamDesc
+	msgHandler middleware.StreamHandler
+	cancel     context.CancelFunc
+}
+
+func (w *wrappedClientStream) SendMsg(m interface{}) error {
+	return w.msgHandler(w.Context(), middleware.StreamSend, m)
+}
+
+func (w *wrappedClientStream) RecvMsg(m interface{}) error {
+	err := w.msgHandler(w.Context(), middleware.StreamRecv, m)
+	// the stream ends on an error, or on the only reply when the server does not stream
+	if w.cancel != nil && (err != nil || !w.desc.ServerStreams) {
+		w.cancel()
+	}
+	return err
+}
+
+func (w *wrappedClientStream) handleMsg(_ context.Context, dir middleware.StreamDirection, m any) error {
+	var err error
+	if dir == middleware.StreamSend {
+		err = w.ClientStream.SendMsg(m)
+	} else {
+		err = w.ClientStream.RecvMsg(m)
+	}
+	if err != nil && err != io.EOF {
+		return errors.FromError(err)
+	}
+	return err
+}
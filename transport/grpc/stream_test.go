@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/kanengo/goutil/pkg/matcher"
+	"github.com/kanengo/ngrpc/middleware"
+	"google.golang.org/grpc"
+)
+
+type testClientStream struct {
+	grpc.ClientStream
+	ctx  context.Context
+	recv []error
+}
+
+func (s *testClientStream) Context() context.Context { return s.ctx }
+
+func (s *testClientStream) RecvMsg(any) error {
+	err := s.recv[0]
+	s.recv = s.recv[1:]
+	return err
+}
+
+func TestWrappedClientStreamCancel(t *testing.T) {
+	tests := []struct {
+		name      string
+		desc      *grpc.StreamDesc
+		recv      []error
+		cancelled []bool
+	}{
+		{"client streaming", &grpc.StreamDesc{ClientStreams: true}, []error{nil}, []bool{true}},
+		{"server streaming", &grpc.StreamDesc{ServerStreams: true}, []error{nil, io.EOF}, []bool{false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ws := &wrappedClientStream{
+				ClientStream: &testClientStream{ctx: ctx, recv: tt.recv},
+				desc:         tt.desc,
+				cancel:       cancel,
+			}
+			ws.msgHandler = ws.handleMsg
+			for i, want := range tt.cancelled {
+				_ = ws.RecvMsg(nil)
+				if got := ctx.Err() != nil; got != want {
+					t.Fatalf("recv %d: expected cancelled %v, got %v", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWithStreamMiddleware(t *testing.T) {
+	m := func(handler middleware.StreamHandler) middleware.StreamHandler { return handler }
+	o := &clientOptions{streamMiddleware: matcher.New[middleware.StreamMiddleware]()}
+	WithStreamMiddleware(m, m)(o)
+	if got := len(o.streamMiddleware.Match("/helloworld.Greeter/SayHelloStream")); got != 2 {
+		t.Fatalf("expected 2 middleware, got %d", got)
+	}
+}
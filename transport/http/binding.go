@@ -0,0 +1,259 @@
+package http
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/kanengo/ngrpc/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// bindValues sets the fields of the proto message v addressed by the dotted field paths in values,
+// e.g. "name" or "book.author". Unknown fields are ignored.
+func bindValues(v any, values map[string][]string) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return errors.BadRequest(fmt.Sprintf("can not bind values into %T", v))
+	}
+	msg := m.ProtoReflect()
+	for key, vs := range values {
+		if err := setFieldPath(msg, strings.Split(key, "."), vs); err != nil {
+			return errors.BadRequest(fmt.Sprintf("bind %s: %s", key, err))
+		}
+	}
+	return nil
+}
+
+func setFieldPath(msg protoreflect.Message, path []string, values []string) error {
+	fields := msg.Descriptor().Fields()
+	fd := fields.ByName(protoreflect.Name(path[0]))
+	if fd == nil {
+		fd = fields.ByJSONName(path[0])
+	}
+	if fd == nil {
+		return nil
+	}
+
+	if len(path) > 1 {
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("field %s is not a message", fd.Name())
+		}
+		return setFieldPath(msg.Mutable(fd).Message(), path[1:], values)
+	}
+
+	switch {
+	case fd.IsMap():
+		return fmt.Errorf("map field %s is not supported", fd.Name())
+	case fd.IsList():
+		list := msg.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseValue(fd, s, func() protoreflect.Message { return list.NewElement().Message() })
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+	case len(values) > 0:
+		v, err := parseValue(fd, values[len(values)-1], func() protoreflect.Message { return msg.NewField(fd).Message() })
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, v)
+	}
+	return nil
+}
+
+func parseValue(fd protoreflect.FieldDescriptor, s string, newMessage func() protoreflect.Message) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		v, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			v, err = base64.URLEncoding.DecodeString(s)
+		}
+		return protoreflect.ValueOfBytes(v), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		v, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid enum value %q", s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		// well known types such as Timestamp and wrappers accept their JSON form
+		m := newMessage()
+		if err := protojson.Unmarshal([]byte(s), m.Interface()); err != nil {
+			if err = protojson.Unmarshal([]byte(strconv.Quote(s)), m.Interface()); err != nil {
+				return protoreflect.Value{}, err
+			}
+		}
+		return protoreflect.ValueOfMessage(m), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+}
+
+// EncodeURL expands the path template with the fields of the proto message v and encodes
+// the remaining populated fields as query parameters, but the ones sent as the request body:
+// none when body is "*", the field at the body path otherwise.
+func EncodeURL(pathTemplate string, v any, body string) string {
+	m, ok := v.(proto.Message)
+	if !ok || m == nil {
+		return pathTemplate
+	}
+	msg := m.ProtoReflect()
+
+	var (
+		b     strings.Builder
+		bound = make(map[string]bool)
+		rest  = pathTemplate
+	)
+	for {
+		start := strings.IndexByte(rest, '{')
+		end := strings.IndexByte(rest, '}')
+		if start < 0 || end < start {
+			b.WriteString(rest)
+			break
+		}
+		b.WriteString(rest[:start])
+		name, sub, _ := strings.Cut(rest[start+1:end], "=")
+		bound[name] = true
+		value := fieldPathValue(msg, strings.Split(name, "."))
+		if strings.Contains(sub, "/") || strings.Contains(sub, "**") {
+			// a multi segment variable keeps its slashes
+			segments := strings.Split(value, "/")
+			for i, s := range segments {
+				segments[i] = url.PathEscape(s)
+			}
+			value = strings.Join(segments, "/")
+		} else {
+			value = url.PathEscape(value)
+		}
+		b.WriteString(value)
+		rest = rest[end+1:]
+	}
+
+	if body == "*" {
+		return b.String()
+	}
+	if body != "" {
+		bound[body] = true
+	}
+	query := url.Values{}
+	encodeQuery(msg, "", "", bound, query)
+	if len(query) == 0 {
+		return b.String()
+	}
+	return b.String() + "?" + query.Encode()
+}
+
+func fieldPathValue(msg protoreflect.Message, path []string) string {
+	fd := msg.Descriptor().Fields().ByName(protoreflect.Name(path[0]))
+	if fd == nil {
+		fd = msg.Descriptor().Fields().ByJSONName(path[0])
+	}
+	if fd == nil || fd.IsList() || fd.IsMap() {
+		return ""
+	}
+	if len(path) > 1 {
+		if fd.Kind() != protoreflect.MessageKind || !msg.Has(fd) {
+			return ""
+		}
+		return fieldPathValue(msg.Get(fd).Message(), path[1:])
+	}
+	return formatValue(fd, msg.Get(fd))
+}
+
+// encodeQuery adds the populated fields of msg not bound by the path template,
+// keys use json names while bound holds the proto field paths of the template.
+func encodeQuery(msg protoreflect.Message, prefix, protoPrefix string, bound map[string]bool, query url.Values) {
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		key := prefix + fd.JSONName()
+		protoKey := protoPrefix + string(fd.Name())
+		if bound[protoKey] || bound[key] || fd.IsMap() {
+			return true
+		}
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				query.Add(key, formatValue(fd, list.Get(i)))
+			}
+		case fd.Kind() == protoreflect.MessageKind && !isWellKnown(fd.Message()):
+			encodeQuery(v.Message(), key+".", protoKey+".", bound, query)
+		default:
+			query.Set(key, formatValue(fd, v))
+		}
+		return true
+	})
+}
+
+// wellKnownTypes are encoded as a single query value in their JSON form.
+var wellKnownTypes = map[protoreflect.FullName]bool{
+	"google.protobuf.Timestamp":   true,
+	"google.protobuf.Duration":    true,
+	"google.protobuf.FieldMask":   true,
+	"google.protobuf.DoubleValue": true,
+	"google.protobuf.FloatValue":  true,
+	"google.protobuf.Int64Value":  true,
+	"google.protobuf.UInt64Value": true,
+	"google.protobuf.Int32Value":  true,
+	"google.protobuf.UInt32Value": true,
+	"google.protobuf.BoolValue":   true,
+	"google.protobuf.StringValue": true,
+	"google.protobuf.BytesValue":  true,
+}
+
+func isWellKnown(md protoreflect.MessageDescriptor) bool {
+	return wellKnownTypes[md.FullName()]
+}
+
+func formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return strconv.Itoa(int(v.Enum()))
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		data, err := protojson.Marshal(v.Message().Interface())
+		if err != nil {
+			return ""
+		}
+		if s, err := strconv.Unquote(string(data)); err == nil {
+			return s
+		}
+		return string(data)
+	}
+	return v.String()
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/kanengo/ngrpc/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestBindValues(t *testing.T) {
+	var st errors.Status
+	err := bindValues(&st, map[string][]string{
+		"code":    {"404"},
+		"message": {"not found"},
+		"unknown": {"ignored"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Code != 404 || st.Message != "not found" {
+		t.Errorf("expect %d %s, got %d %s", 404, "not found", st.Code, st.Message)
+	}
+
+	var fd descriptorpb.FieldDescriptorProto
+	err = bindValues(&fd, map[string][]string{
+		"type":           {"TYPE_STRING"},
+		"jsonName":       {"json"},
+		"options.packed": {"true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fd.GetType() != descriptorpb.FieldDescriptorProto_TYPE_STRING || fd.GetJsonName() != "json" || !fd.GetOptions().GetPacked() {
+		t.Errorf("unexpected %v", &fd)
+	}
+
+	var fdp descriptorpb.FileDescriptorProto
+	err = bindValues(&fdp, map[string][]string{
+		"dependency":        {"a.proto", "b.proto"},
+		"public_dependency": {"1", "2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fdp.Dependency, []string{"a.proto", "b.proto"}) || !reflect.DeepEqual(fdp.PublicDependency, []int32{1, 2}) {
+		t.Errorf("unexpected %v", &fdp)
+	}
+
+	var wrapper wrapperspb.Int32Value
+	if err = bindValues(&wrapper, map[string][]string{"value": {"abc"}}); !errors.IsBadRequest(err) {
+		t.Errorf("expect bad request, got %v", err)
+	}
+}
+
+func TestEncodeURL(t *testing.T) {
+	st := &errors.Status{Code: 404, Message: "not found/user", Reason: "a b"}
+	if got, want := EncodeURL("/v1/{message}", st, "*"), "/v1/not%20found%2Fuser"; got != want {
+		t.Errorf("expect %s, got %s", want, got)
+	}
+	if got, want := EncodeURL("/v1/{message=**}", st, "*"), "/v1/not%20found/user"; got != want {
+		t.Errorf("expect %s, got %s", want, got)
+	}
+	if got, want := EncodeURL("/v1/{reason}", st, ""), "/v1/a%20b?code=404&message=not+found%2Fuser"; got != want {
+		t.Errorf("expect %s, got %s", want, got)
+	}
+
+	fd := &descriptorpb.FieldDescriptorProto{
+		Name:    proto.String("f"),
+		Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		Options: &descriptorpb.FieldOptions{Packed: proto.Bool(true)},
+	}
+	got := EncodeURL("/v1/{name}", fd, "")
+	if want := "/v1/f?options.packed=true&type=TYPE_STRING"; got != want {
+		t.Errorf("expect %s, got %s", want, got)
+	}
+
+	// the body field is sent in the request body only
+	if got, want := EncodeURL("/v1/{name}", fd, "options"), "/v1/f?type=TYPE_STRING"; got != want {
+		t.Errorf("expect %s, got %s", want, got)
+	}
+
+	var bound descriptorpb.FieldDescriptorProto
+	u, _ := url.Parse(got)
+	if err := bindValues(&bound, u.Query()); err != nil {
+		t.Fatal(err)
+	}
+	if bound.GetType() != fd.GetType() || bound.GetOptions().GetPacked() != true {
+		t.Errorf("expect round trip, got %v", &bound)
+	}
+}
@@ -0,0 +1,338 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kanengo/goutil/pkg/matcher"
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/registry"
+	"github.com/kanengo/ngrpc/selector"
+	"github.com/kanengo/ngrpc/selector/balancer/p2c"
+	"github.com/kanengo/ngrpc/transport"
+)
+
+type ClientOption func(options *clientOptions)
+
+// WithEndpoint with the server address, e.g. "127.0.0.1:8000" or "discovery:///helloworld" with WithDiscovery.
+func WithEndpoint(endpoint string) ClientOption {
+	return func(options *clientOptions) {
+		options.endpoint = endpoint
+	}
+}
+
+func WithTlsConfig(tlsConfig *tls.Config) ClientOption {
+	return func(options *clientOptions) {
+		options.tlsConf = tlsConfig
+	}
+}
+
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(options *clientOptions) {
+		options.timeout = timeout
+	}
+}
+
+func WithMiddleware(ms ...middleware.Middleware) ClientOption {
+	return func(options *clientOptions) {
+		options.middleware.Use(ms...)
+	}
+}
+
+// WithMethodMiddleware with middleware for the operations matched by selector.
+func WithMethodMiddleware(selector string, ms ...middleware.Middleware) ClientOption {
+	return func(options *clientOptions) {
+		options.middleware.Add(selector, ms...)
+	}
+}
+
+func WithDiscovery(discovery registry.Discovery) ClientOption {
+	return func(options *clientOptions) {
+		options.discovery = discovery
+	}
+}
+
+func WithNodeFilters(nodeFilters ...selector.Filter[selector.Node]) ClientOption {
+	return func(options *clientOptions) {
+		options.nodeFilters = nodeFilters
+	}
+}
+
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(options *clientOptions) {
+		options.transport = rt
+	}
+}
+
+func WithRequestEncoder(encoder EncodeRequestFunc) ClientOption {
+	return func(options *clientOptions) {
+		options.encoder = encoder
+	}
+}
+
+func WithResponseDecoder(decoder DecodeResponseFunc) ClientOption {
+	return func(options *clientOptions) {
+		options.decoder = decoder
+	}
+}
+
+func WithErrorDecoder(errorDecoder DecodeErrorFunc) ClientOption {
+	return func(options *clientOptions) {
+		options.errorDecoder = errorDecoder
+	}
+}
+
+type clientOptions struct {
+	endpoint     string
+	tlsConf      *tls.Config
+	timeout      time.Duration
+	middleware   matcher.Matcher[middleware.Middleware]
+	discovery    registry.Discovery
+	nodeFilters  []selector.Filter[selector.Node]
+	transport    http.RoundTripper
+	encoder      EncodeRequestFunc
+	decoder      DecodeResponseFunc
+	errorDecoder DecodeErrorFunc
+}
+
+// CallOption configures a single Invoke.
+type CallOption func(info *callInfo)
+
+type callInfo struct {
+	operation    string
+	pathTemplate string
+	contentType  string
+}
+
+// Operation with the name matched by the client middleware selectors.
+func Operation(operation string) CallOption {
+	return func(info *callInfo) {
+		info.operation = operation
+	}
+}
+
+func PathTemplate(pattern string) CallOption {
+	return func(info *callInfo) {
+		info.pathTemplate = pattern
+	}
+}
+
+func ContentType(contentType string) CallOption {
+	return func(info *callInfo) {
+		info.contentType = contentType
+	}
+}
+
+// Client is an HTTP client running the middleware chain and picking nodes through the selector.
+type Client struct {
+	opts     clientOptions
+	target   *Target
+	r        *resolver
+	cc       *http.Client
+	selector selector.Selector
+	scheme   string
+}
+
+func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
+	options := clientOptions{
+		timeout:      2 * time.Second,
+		middleware:   matcher.New[middleware.Middleware](),
+		transport:    http.DefaultTransport,
+		encoder:      DefaultRequestEncoder,
+		decoder:      DefaultResponseDecoder,
+		errorDecoder: DefaultErrorDecoder,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.tlsConf != nil {
+		if tr, ok := options.transport.(*http.Transport); ok {
+			tr = tr.Clone()
+			tr.TLSClientConfig = options.tlsConf
+			options.transport = tr
+		}
+	}
+	insecure := options.tlsConf == nil
+	target, err := parseTarget(options.endpoint, insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := selector.GlobalSelectorBuilder()
+	if builder == nil {
+		builder = p2c.NewBuilder()
+	}
+	c := &Client{
+		opts:     options,
+		target:   target,
+		cc:       &http.Client{Transport: options.transport},
+		selector: builder.Build(),
+		scheme:   target.Scheme,
+	}
+
+	switch target.Scheme {
+	case "http", "https":
+	case "discovery":
+		if options.discovery == nil {
+			return nil, fmt.Errorf("[http client] endpoint %s requires WithDiscovery", options.endpoint)
+		}
+		r, err := newResolver(ctx, options.discovery, target, c.selector, insecure)
+		if err != nil {
+			return nil, fmt.Errorf("[http client] new resolver failed: %w", err)
+		}
+		c.r = r
+		// the nodes are resolved with the scheme matching the tls config
+		c.scheme = "http"
+		if !insecure {
+			c.scheme = "https"
+		}
+	default:
+		return nil, fmt.Errorf("[http client] unsupported endpoint scheme %q", target.Scheme)
+	}
+
+	return c, nil
+}
+
+// Invoke sends args to path with the http method and decodes the response into reply.
+// The operation defaults to the path template, then to the path, which is unbounded
+// as a metric label or log field, so raw calls should set one of them.
+func (c *Client) Invoke(ctx context.Context, method, path string, args any, reply any, opts ...CallOption) error {
+	info := callInfo{
+		contentType: contentTypeJSON,
+	}
+	for _, o := range opts {
+		o(&info)
+	}
+	if info.operation == "" {
+		info.operation = info.pathTemplate
+	}
+	if info.operation == "" {
+		info.operation, _, _ = strings.Cut(path, "?")
+	}
+
+	var body io.Reader
+	if args != nil {
+		data, err := c.opts.encoder(ctx, info.contentType, args)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("%s://%s%s", c.scheme, c.target.Authority, path), body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", info.contentType)
+	}
+
+	tr := &Transport{
+		endpoint:     c.opts.endpoint,
+		fullMethod:   info.operation,
+		pathTemplate: info.pathTemplate,
+		reqHeader:    headerCarrier(req.Header),
+		replyHeader:  headerCarrier{},
+		request:      req,
+	}
+	ctx = transport.NewClientContext(ctx, tr)
+	if c.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.timeout)
+		defer cancel()
+	}
+
+	h := func(ctx context.Context, in any) (any, error) {
+		r := req.WithContext(ctx)
+		// a fresh body on every call, middleware may retry
+		if r.GetBody != nil {
+			body, err := r.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+		res, err := c.do(r)
+		if res != nil {
+			tr.replyHeader = headerCarrier(res.Header)
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if err = c.opts.decoder(ctx, res, reply); err != nil {
+			return nil, err
+		}
+		return reply, nil
+	}
+	if ms := c.opts.middleware.Match(info.operation); len(ms) > 0 {
+		h = middleware.Chain(ms...)(h)
+	}
+
+	_, err = h(ctx, args)
+	return err
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	var done selector.DoneFunc
+	if c.r != nil {
+		var (
+			node selector.Node
+			err  error
+		)
+		node, done, err = c.selector.Select(req.Context(), func(options *selector.SelectOptions) {
+			options.NodeFilters = c.opts.nodeFilters
+		})
+		if err != nil {
+			return nil, err
+		}
+		req.URL.Host = node.Address()
+		req.Host = node.Address()
+		if peer, ok := selector.FromPeerContext(req.Context()); ok {
+			peer.Node = node
+		}
+	}
+
+	res, err := c.cc.Do(req)
+	if err == nil {
+		err = c.opts.errorDecoder(req.Context(), res)
+	} else {
+		err = transportError(req.Context(), err)
+	}
+	if done != nil {
+		done(req.Context(), selector.DoneInfo{Err: err, BytesSent: true, BytesReceived: res != nil, ReplyMD: replyMD(res)})
+	}
+	return res, err
+}
+
+func (c *Client) Close() error {
+	if c.r != nil {
+		return c.r.Close()
+	}
+	return nil
+}
+
+func replyMD(res *http.Response) selector.ReplyMD {
+	if res == nil {
+		return headerCarrier{}
+	}
+	return headerCarrier(res.Header)
+}
+
+// transportError converts a failed round trip into *errors.Error.
+func transportError(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return errors.Timeout(err.Error()).WithCause(err)
+	case context.Canceled:
+		return errors.ClientClosed(err.Error()).WithCause(err)
+	}
+	return errors.ServiceUnavailable(err.Error()).WithCause(err)
+}
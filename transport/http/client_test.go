@@ -0,0 +1,235 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/transport"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		insecure bool
+		want     *Target
+	}{
+		{"127.0.0.1:8000", true, &Target{Scheme: "http", Authority: "127.0.0.1:8000"}},
+		{"127.0.0.1:8000", false, &Target{Scheme: "https", Authority: "127.0.0.1:8000"}},
+		{"discovery:///helloworld", true, &Target{Scheme: "discovery", Authority: "", Endpoint: "helloworld"}},
+	}
+	for _, test := range tests {
+		got, err := parseTarget(test.endpoint, test.insecure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: expect %+v, got %+v", test.endpoint, test.want, got)
+		}
+	}
+}
+
+func TestClientScheme(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"message":"secure"}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, WithEndpoint(srv.URL), WithTransport(srv.Client().Transport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := &testReply{}
+	if err = client.Invoke(ctx, http.MethodGet, "/", nil, reply); err != nil || reply.Message != "secure" {
+		t.Fatalf("expect the https endpoint honoured, got %v %v", reply, err)
+	}
+
+	if _, err = NewClient(ctx, WithEndpoint("discovery:///helloworld")); err == nil {
+		t.Error("expect an error for a discovery endpoint without discovery")
+	}
+	if _, err = NewClient(ctx, WithEndpoint("grpc://127.0.0.1:9000")); err == nil {
+		t.Error("expect an error for an unsupported scheme")
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer()
+	srv.Route(http.MethodGet, "/helloworld/{name}", "/helloworld.Greeter/SayHello", func(ctx Context) error {
+		name := ctx.Vars()["name"]
+		if name == "error" {
+			return errors.BadRequest("invalid name").WithReason("INVALID_NAME").WithMetadata(map[string]string{"name": name})
+		}
+		return ctx.Result(http.StatusOK, &testReply{Message: fmt.Sprintf("Hello %s", name)})
+	})
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			panic(err)
+		}
+	}()
+	defer func() { _ = srv.Stop(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	e, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	client, err := NewClient(ctx,
+		WithEndpoint(e.Host),
+		WithTimeout(time.Second),
+		WithMethodMiddleware("/helloworld.Greeter/*", func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+				count++
+				if tr, ok := transport.FromClientContext(ctx); !ok || tr.FullMethod() != "/helloworld.Greeter/SayHello" {
+					t.Errorf("unexpected transport %v", tr)
+				}
+				return handler(ctx, req)
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var reply testReply
+	path := "/helloworld/" + "a%2Fb"
+	err = client.Invoke(ctx, http.MethodGet, path, nil, &reply, Operation("/helloworld.Greeter/SayHello"), PathTemplate("/helloworld/{name}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Message != "Hello a/b" {
+		t.Errorf("expect %s, got %s", "Hello a/b", reply.Message)
+	}
+
+	err = client.Invoke(ctx, http.MethodGet, "/helloworld/error", nil, &reply, Operation("/helloworld.Greeter/SayHello"))
+	if !errors.IsBadRequest(err) || errors.Reason(err) != "INVALID_NAME" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if se := errors.FromError(err); se.Metadata["name"] != "error" {
+		t.Errorf("expect metadata %v, got %v", "error", se.Metadata)
+	}
+	if count != 2 {
+		t.Errorf("expect %d, got %d", 2, count)
+	}
+
+	err = client.Invoke(ctx, http.MethodGet, "/unknown", nil, &reply)
+	if !errors.IsNotFound(err) {
+		t.Errorf("expect not found, got %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expect %d, got %d", 2, count)
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer()
+	var calls int
+	srv.Route(http.MethodPost, "/echo", "/helloworld.Greeter/Echo", func(ctx Context) error {
+		calls++
+		var in testReply
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		if calls == 1 {
+			return errors.ServiceUnavailable("retry")
+		}
+		return ctx.Result(http.StatusOK, &in)
+	})
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			panic(err)
+		}
+	}()
+	defer func() { _ = srv.Stop(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	e, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(ctx,
+		WithEndpoint(e.Host),
+		WithMiddleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				if tr, ok := transport.FromClientContext(ctx); ok {
+					tr.ReplyHeader().Set("x-before", "1")
+				}
+				reply, err := handler(ctx, req)
+				if errors.IsServiceUnavailable(err) {
+					return handler(ctx, req)
+				}
+				return reply, err
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var reply testReply
+	if err = client.Invoke(ctx, http.MethodPost, "/echo", &testReply{Message: "hello"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || reply.Message != "hello" {
+		t.Errorf("expect the body sent again on retry, got %d calls and %q", calls, reply.Message)
+	}
+}
+
+func TestClientOperation(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer()
+	srv.Route(http.MethodGet, "/users/{id}", "", func(ctx Context) error {
+		return ctx.Result(http.StatusOK, &testReply{})
+	})
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			panic(err)
+		}
+	}()
+	defer func() { _ = srv.Stop(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	e, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var operations []string
+	client, err := NewClient(ctx,
+		WithEndpoint(e.Host),
+		WithMiddleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				tr, _ := transport.FromClientContext(ctx)
+				operations = append(operations, tr.FullMethod())
+				return handler(ctx, req)
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var reply testReply
+	if err = client.Invoke(ctx, http.MethodGet, "/users/42", nil, &reply, PathTemplate("/users/{id}")); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Invoke(ctx, http.MethodGet, "/users/42?verbose=true", nil, &reply); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/users/{id}", "/users/42"}
+	if !reflect.DeepEqual(operations, want) {
+		t.Errorf("expect %v, got %v", want, operations)
+	}
+}
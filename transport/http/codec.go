@@ -0,0 +1,135 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/kanengo/ngrpc/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const contentTypeJSON = "application/json"
+
+// DecodeRequestFunc decodes the request body into v.
+type DecodeRequestFunc func(r *http.Request, v any) error
+
+// EncodeResponseFunc encodes v into the response.
+type EncodeResponseFunc func(w http.ResponseWriter, r *http.Request, v any) error
+
+// EncodeErrorFunc encodes err into the response.
+type EncodeErrorFunc func(w http.ResponseWriter, r *http.Request, err error)
+
+var (
+	marshalOptions = protojson.MarshalOptions{
+		UseProtoNames: true,
+	}
+	unmarshalOptions = protojson.UnmarshalOptions{
+		DiscardUnknown: true,
+	}
+)
+
+func marshalJSON(v any) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return marshalOptions.Marshal(m)
+	}
+	return json.Marshal(v)
+}
+
+func unmarshalJSON(data []byte, v any) error {
+	if m, ok := v.(proto.Message); ok {
+		return unmarshalOptions.Unmarshal(data, m)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// DefaultRequestDecoder decodes a JSON body, proto messages are decoded with protojson.
+// A body beyond the MaxBodySize of the server is rejected with 413 Request Entity Too Large.
+func DefaultRequestDecoder(r *http.Request, v any) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return errors.Newf(http.StatusRequestEntityTooLarge, "request body exceeds %d bytes", mbe.Limit)
+		}
+		return errors.BadRequest(err.Error())
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if err = unmarshalJSON(data, v); err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	return nil
+}
+
+// DefaultResponseEncoder encodes v as JSON, proto messages are encoded with protojson.
+func DefaultResponseEncoder(w http.ResponseWriter, r *http.Request, v any) error {
+	if v == nil {
+		return nil
+	}
+	data, err := marshalJSON(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", contentTypeJSON)
+	_, err = w.Write(data)
+	return err
+}
+
+// DefaultErrorEncoder encodes err as the JSON of errors.Status with the mapped http status.
+func DefaultErrorEncoder(w http.ResponseWriter, r *http.Request, err error) {
+	se := errors.FromError(err)
+	data, err := marshalJSON(&se.Status)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(errors.HTTPStatus(se.Code))
+	_, _ = w.Write(data)
+}
+
+// EncodeRequestFunc encodes the request body of an outgoing call.
+type EncodeRequestFunc func(ctx context.Context, contentType string, in any) ([]byte, error)
+
+// DecodeResponseFunc decodes the response body of an outgoing call into out.
+type DecodeResponseFunc func(ctx context.Context, res *http.Response, out any) error
+
+// DecodeErrorFunc returns the error carried by a response, nil for a successful one.
+type DecodeErrorFunc func(ctx context.Context, res *http.Response) error
+
+// DefaultRequestEncoder encodes in as JSON, proto messages are encoded with protojson.
+func DefaultRequestEncoder(_ context.Context, _ string, in any) ([]byte, error) {
+	return marshalJSON(in)
+}
+
+// DefaultResponseDecoder decodes a JSON body, proto messages are decoded with protojson.
+func DefaultResponseDecoder(_ context.Context, res *http.Response, out any) error {
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 || out == nil {
+		return nil
+	}
+	return unmarshalJSON(data, out)
+}
+
+// DefaultErrorDecoder decodes the errors.Status written by DefaultErrorEncoder back into *errors.Error.
+func DefaultErrorDecoder(_ context.Context, res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode <= 299 {
+		return nil
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err == nil {
+		st := new(errors.Status)
+		if err = unmarshalJSON(data, st); err == nil && st.Code != 0 {
+			return errors.New(st.Code, st.Message).WithMetadata(st.Metadata).WithReason(st.Reason)
+		}
+	}
+	return errors.New(int32(res.StatusCode), string(data))
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/kanengo/ngrpc/middleware"
+)
+
+var _ Context = (*wrapper)(nil)
+
+// HandlerFunc handles a routed request, a returned error is written by the server error encoder.
+type HandlerFunc func(Context) error
+
+// Context is the context of a routed request.
+type Context interface {
+	context.Context
+	Vars() map[string]string
+	Query() url.Values
+	Request() *http.Request
+	Response() http.ResponseWriter
+	// Middleware wraps h with the server middleware matching the route operation.
+	Middleware(h middleware.Handler) middleware.Handler
+	// Bind decodes the request body into v.
+	Bind(v any) error
+	// BindVars sets the fields of the proto message v from the path variables.
+	BindVars(v any) error
+	// BindQuery sets the fields of the proto message v from the query parameters.
+	BindQuery(v any) error
+	// Result writes v as the response body with the http status code.
+	Result(code int, v any) error
+}
+
+type wrapper struct {
+	context.Context
+	srv  *Server
+	w    http.ResponseWriter
+	req  *http.Request
+	vars map[string]string
+	op   string
+}
+
+func (c *wrapper) Vars() map[string]string {
+	return c.vars
+}
+
+func (c *wrapper) Query() url.Values {
+	return c.req.URL.Query()
+}
+
+func (c *wrapper) Request() *http.Request {
+	return c.req
+}
+
+func (c *wrapper) Response() http.ResponseWriter {
+	return c.w
+}
+
+func (c *wrapper) Middleware(h middleware.Handler) middleware.Handler {
+	if ms := c.srv.middleware.Match(c.op); len(ms) > 0 {
+		return middleware.Chain(ms...)(h)
+	}
+	return h
+}
+
+func (c *wrapper) Bind(v any) error {
+	return c.srv.decBody(c.req, v)
+}
+
+func (c *wrapper) BindVars(v any) error {
+	values := make(map[string][]string, len(c.vars))
+	for k, val := range c.vars {
+		values[k] = []string{val}
+	}
+	return bindValues(v, values)
+}
+
+func (c *wrapper) BindQuery(v any) error {
+	return bindValues(v, c.Query())
+}
+
+func (c *wrapper) Result(code int, v any) error {
+	w := &statusWriter{ResponseWriter: c.w, code: code}
+	if err := c.srv.enc(w, c.req, v); err != nil {
+		return err
+	}
+	w.writeHeader()
+	return nil
+}
+
+// statusWriter delays the status code until the encoder has set its headers.
+type statusWriter struct {
+	http.ResponseWriter
+	code    int
+	written bool
+}
+
+func (w *statusWriter) writeHeader() {
+	if !w.written {
+		w.written = true
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.code = code
+	w.writeHeader()
+}
+
+func (w *statusWriter) Write(data []byte) (int, error) {
+	w.writeHeader()
+	return w.ResponseWriter.Write(data)
+}
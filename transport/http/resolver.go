@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kanengo/goutil/pkg/log"
+	"github.com/kanengo/ngrpc/internal/endpoint"
+	"github.com/kanengo/ngrpc/registry"
+	"github.com/kanengo/ngrpc/selector"
+	"go.uber.org/zap"
+)
+
+// Target is the parsed client endpoint, e.g. "127.0.0.1:8000" or "discovery:///helloworld".
+type Target struct {
+	Scheme    string
+	Authority string
+	Endpoint  string
+}
+
+func parseTarget(ept string, insecure bool) (*Target, error) {
+	if !strings.Contains(ept, "://") {
+		if insecure {
+			ept = "http://" + ept
+		} else {
+			ept = "https://" + ept
+		}
+	}
+	u, err := url.Parse(ept)
+	if err != nil {
+		return nil, err
+	}
+	target := &Target{Scheme: u.Scheme, Authority: u.Host}
+	if len(u.Path) > 1 {
+		target.Endpoint = u.Path[1:]
+	}
+	return target, nil
+}
+
+// resolver keeps the selector nodes in sync with the discovery instances of the target.
+type resolver struct {
+	rebalancer selector.ReBalancer
+	target     *Target
+	watcher    registry.Watcher
+	insecure   bool
+}
+
+func newResolver(ctx context.Context, discovery registry.Discovery, target *Target, rebalancer selector.ReBalancer, insecure bool) (*resolver, error) {
+	watcher, err := discovery.Watch(ctx, target.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	r := &resolver{
+		rebalancer: rebalancer,
+		target:     target,
+		watcher:    watcher,
+		insecure:   insecure,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		services, err := watcher.Next()
+		if err == nil && !r.update(services) {
+			err = fmt.Errorf("[http resolver] no available endpoint for %s", target.Endpoint)
+		}
+		done <- err
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = watcher.Stop()
+		return nil, err
+	}
+
+	go r.watch()
+
+	return r, nil
+}
+
+func (r *resolver) watch() {
+	for {
+		services, err := r.watcher.Next()
+		if err != nil {
+			if stderrors.Is(err, context.Canceled) {
+				return
+			}
+			log.Error("[http resolver] Failed to watch discovery", zap.String("endpoint", r.target.Endpoint), zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		r.update(services)
+	}
+}
+
+func (r *resolver) update(services []*registry.ServiceInstance) bool {
+	nodes := make([]selector.Node, 0, len(services))
+	for _, ins := range services {
+		ept, err := endpoint.ParseEndpoint(ins.Endpoints, endpoint.Scheme("http", !r.insecure))
+		if err != nil {
+			log.Error("[http resolver] Failed to parse discovery endpoint", zap.Error(err))
+			continue
+		}
+		if ept == "" {
+			continue
+		}
+		nodes = append(nodes, selector.NewNode("http", ept, ins))
+	}
+	if len(nodes) == 0 {
+		log.Warn("[http resolver] Not any endpoint found", zap.Any("ins", services))
+		return false
+	}
+	r.rebalancer.Apply(nodes)
+	return true
+}
+
+func (r *resolver) Close() error {
+	return r.watcher.Stop()
+}
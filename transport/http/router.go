@@ -0,0 +1,189 @@
+package http
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+type segmentKind uint8
+
+const (
+	segmentLiteral segmentKind = iota
+	segmentWildcard
+	segmentDeepWildcard
+)
+
+type segment struct {
+	kind    segmentKind
+	literal string
+}
+
+// variable binds the path segments in [start, end) of the template.
+type variable struct {
+	name       string
+	start, end int
+}
+
+// pathTemplate is a google.api.http path template, e.g. "/v1/{name=shelves/*}/books/{book}:publish".
+type pathTemplate struct {
+	raw      string
+	segments []segment
+	vars     []variable
+	verb     string
+}
+
+func parseTemplate(tpl string) (*pathTemplate, error) {
+	if !strings.HasPrefix(tpl, "/") {
+		return nil, fmt.Errorf("path template %q must start with /", tpl)
+	}
+	t := &pathTemplate{raw: tpl}
+	rest := tpl[1:]
+	if i := strings.LastIndex(rest, ":"); i >= 0 && !strings.ContainsAny(rest[i:], "/}") {
+		t.verb = rest[i+1:]
+		rest = rest[:i]
+	}
+
+	for len(rest) > 0 {
+		if rest[0] == '{' {
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return nil, fmt.Errorf("path template %q has unclosed variable", tpl)
+			}
+			name, sub, ok := strings.Cut(rest[1:end], "=")
+			if !ok {
+				sub = "*"
+			}
+			v := variable{name: name, start: len(t.segments)}
+			for _, s := range strings.Split(sub, "/") {
+				t.segments = append(t.segments, newSegment(s))
+			}
+			v.end = len(t.segments)
+			t.vars = append(t.vars, v)
+			rest = rest[end+1:]
+		} else {
+			s, _, _ := strings.Cut(rest, "/")
+			t.segments = append(t.segments, newSegment(s))
+			rest = rest[len(s):]
+		}
+		if len(rest) > 0 {
+			if rest[0] != '/' {
+				return nil, fmt.Errorf("path template %q is malformed", tpl)
+			}
+			rest = rest[1:]
+		}
+	}
+
+	for i, s := range t.segments {
+		if s.kind == segmentDeepWildcard && i != len(t.segments)-1 {
+			return nil, fmt.Errorf("path template %q: ** must be the last segment", tpl)
+		}
+	}
+
+	return t, nil
+}
+
+func newSegment(s string) segment {
+	switch s {
+	case "*":
+		return segment{kind: segmentWildcard}
+	case "**":
+		return segment{kind: segmentDeepWildcard}
+	}
+	return segment{kind: segmentLiteral, literal: s}
+}
+
+func (t *pathTemplate) match(path string) (map[string]string, bool) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, false
+	}
+	path = path[1:]
+	if t.verb != "" {
+		if !strings.HasSuffix(path, ":"+t.verb) {
+			return nil, false
+		}
+		path = strings.TrimSuffix(path, ":"+t.verb)
+	}
+	var parts []string
+	if path != "" {
+		parts = strings.Split(path, "/")
+	}
+
+	// pos[i] is the index in parts where template segment i begins.
+	pos := make([]int, len(t.segments)+1)
+	j := 0
+	for i, s := range t.segments {
+		pos[i] = j
+		switch s.kind {
+		case segmentDeepWildcard:
+			j = len(parts)
+		case segmentWildcard:
+			if j >= len(parts) || parts[j] == "" {
+				return nil, false
+			}
+			j++
+		default:
+			if j >= len(parts) || parts[j] != s.literal {
+				return nil, false
+			}
+			j++
+		}
+	}
+	if j != len(parts) {
+		return nil, false
+	}
+	pos[len(t.segments)] = j
+
+	vars := make(map[string]string, len(t.vars))
+	for _, v := range t.vars {
+		values := parts[pos[v.start]:pos[v.end]]
+		for i, value := range values {
+			unescaped, err := url.PathUnescape(value)
+			if err != nil {
+				return nil, false
+			}
+			values[i] = unescaped
+		}
+		vars[v.name] = strings.Join(values, "/")
+	}
+
+	return vars, true
+}
+
+type route struct {
+	method    string
+	operation string
+	tpl       *pathTemplate
+	h         HandlerFunc
+}
+
+type router struct {
+	mu     sync.RWMutex
+	routes []*route
+}
+
+func (r *router) add(rt *route) {
+	r.mu.Lock()
+	r.routes = append(r.routes, rt)
+	r.mu.Unlock()
+}
+
+// match returns the first route registered for method and path,
+// allowed lists the methods of the routes matching path with another method.
+func (r *router) match(method, path string) (rt *route, vars map[string]string, allowed []string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, candidate := range r.routes {
+		v, ok := candidate.tpl.match(path)
+		if !ok {
+			continue
+		}
+		if candidate.method != method {
+			allowed = append(allowed, candidate.method)
+			continue
+		}
+		return candidate, v, nil
+	}
+	return nil, nil, allowed
+}
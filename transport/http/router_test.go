@@ -0,0 +1,49 @@
+package http
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		tpl  string
+		path string
+		want map[string]string
+		ok   bool
+	}{
+		{name: "literal", tpl: "/helloworld", path: "/helloworld", want: map[string]string{}, ok: true},
+		{name: "variable", tpl: "/helloworld/{name}", path: "/helloworld/kratos", want: map[string]string{"name": "kratos"}, ok: true},
+		{name: "escaped", tpl: "/helloworld/{name}", path: "/helloworld/a%20b", want: map[string]string{"name": "a b"}, ok: true},
+		{name: "missing", tpl: "/helloworld/{name}", path: "/helloworld", ok: false},
+		{name: "too long", tpl: "/helloworld/{name}", path: "/helloworld/a/b", ok: false},
+		{name: "sub template", tpl: "/v1/{name=shelves/*}/books", path: "/v1/shelves/1/books", want: map[string]string{"name": "shelves/1"}, ok: true},
+		{name: "deep wildcard", tpl: "/v1/{path=**}", path: "/v1/a/b/c", want: map[string]string{"path": "a/b/c"}, ok: true},
+		{name: "verb", tpl: "/v1/{name}:publish", path: "/v1/book:publish", want: map[string]string{"name": "book"}, ok: true},
+		{name: "verb mismatch", tpl: "/v1/{name}:publish", path: "/v1/book", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := parseTemplate(tt.tpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := tpl.match(tt.path)
+			if ok != tt.ok {
+				t.Fatalf("match() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTemplateError(t *testing.T) {
+	for _, tpl := range []string{"helloworld", "/v1/{name", "/v1/**/books"} {
+		if _, err := parseTemplate(tpl); err == nil {
+			t.Errorf("expect error for %s", tpl)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	stderrors "errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kanengo/goutil/pkg/host"
+	"github.com/kanengo/goutil/pkg/log"
+	"github.com/kanengo/goutil/pkg/matcher"
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/transport"
+	"go.uber.org/zap"
+)
+
+var (
+	_ transport.Server = (*Server)(nil)
+	_ http.Handler     = (*Server)(nil)
+)
+
+type ServerOption func(s *Server)
+
+func Address(addr string) ServerOption {
+	return func(s *Server) {
+		s.address = addr
+	}
+}
+
+func Timeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.timeout = timeout
+	}
+}
+
+// MaxBodySize with the maximum size in bytes of the routed request bodies, a larger body
+// fails Bind with 413 Request Entity Too Large. A non-positive size disables the limit.
+func MaxBodySize(size int64) ServerOption {
+	return func(s *Server) {
+		s.maxBodySize = size
+	}
+}
+
+func Middleware(m ...middleware.Middleware) ServerOption {
+	return func(s *Server) {
+		s.middleware.Use(m...)
+	}
+}
+
+func TLSConfig(c *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.tlsConf = c
+	}
+}
+
+func Listener(lis net.Listener) ServerOption {
+	return func(s *Server) {
+		s.lis = lis
+	}
+}
+
+func RequestDecoder(dec DecodeRequestFunc) ServerOption {
+	return func(s *Server) {
+		s.decBody = dec
+	}
+}
+
+func ResponseEncoder(en EncodeResponseFunc) ServerOption {
+	return func(s *Server) {
+		s.enc = en
+	}
+}
+
+func ErrorEncoder(en EncodeErrorFunc) ServerOption {
+	return func(s *Server) {
+		s.ene = en
+	}
+}
+
+type Server struct {
+	*http.Server
+	baseCtx     context.Context
+	tlsConf     *tls.Config
+	address     string
+	endpoint    *url.URL
+	timeout     time.Duration
+	maxBodySize int64
+	middleware  matcher.Matcher[middleware.Middleware]
+	router      *router
+	mux         *http.ServeMux
+	decBody     DecodeRequestFunc
+	enc         EncodeResponseFunc
+	ene         EncodeErrorFunc
+	lis         net.Listener
+}
+
+func NewServer(opts ...ServerOption) *Server {
+	srv := &Server{
+		baseCtx:     context.Background(),
+		timeout:     3 * time.Second,
+		maxBodySize: 4 << 20,
+		middleware:  matcher.New[middleware.Middleware](),
+		router:      &router{},
+		mux:         http.NewServeMux(),
+		decBody:     DefaultRequestDecoder,
+		enc:         DefaultResponseEncoder,
+		ene:         DefaultErrorEncoder,
+	}
+
+	for _, o := range opts {
+		o(srv)
+	}
+
+	srv.Server = &http.Server{
+		Handler:   srv,
+		TLSConfig: srv.tlsConf,
+		BaseContext: func(net.Listener) context.Context {
+			return srv.baseCtx
+		},
+	}
+
+	return srv
+}
+
+func (s *Server) AddMiddleware(selector string, ms ...middleware.Middleware) {
+	s.middleware.Add(selector, ms...)
+}
+
+// Route registers h for method and the google.api.http style path template,
+// operation is the name matched by middleware selectors and defaults to path.
+func (s *Server) Route(method, path, operation string, h HandlerFunc) {
+	tpl, err := parseTemplate(path)
+	if err != nil {
+		panic(err)
+	}
+	if operation == "" {
+		operation = path
+	}
+	s.router.add(&route{
+		method:    strings.ToUpper(method),
+		operation: operation,
+		tpl:       tpl,
+		h:         h,
+	})
+}
+
+// Handle registers a plain http.Handler for requests not matched by any route, it bypasses the middleware.
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+func (s *Server) HandleFunc(pattern string, h http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, h)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rt, vars, allowed := s.router.match(req.Method, req.URL.EscapedPath())
+	if rt == nil {
+		if _, pattern := s.mux.Handler(req); pattern != "" {
+			s.mux.ServeHTTP(w, req)
+			return
+		}
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			s.ene(w, req, errors.New(http.StatusMethodNotAllowed, "method not allowed"))
+			return
+		}
+		s.ene(w, req, errors.NotFound("no route for "+req.URL.Path))
+		return
+	}
+
+	ctx := req.Context()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	tr := &Transport{
+		endpoint:     "",
+		fullMethod:   rt.operation,
+		pathTemplate: rt.tpl.raw,
+		reqHeader:    headerCarrier(req.Header),
+		replyHeader:  headerCarrier(w.Header()),
+		request:      req,
+	}
+	if s.endpoint != nil {
+		tr.endpoint = s.endpoint.String()
+	}
+	ctx = transport.NewServerContext(ctx, tr)
+	req = req.WithContext(ctx)
+	if s.maxBodySize > 0 {
+		req.Body = http.MaxBytesReader(w, req.Body, s.maxBodySize)
+	}
+
+	c := &wrapper{
+		Context: ctx,
+		srv:     s,
+		w:       w,
+		req:     req,
+		vars:    vars,
+		op:      rt.operation,
+	}
+	if err := rt.h(c); err != nil {
+		s.ene(w, req, err)
+	}
+}
+
+func (s *Server) Endpoint() (*url.URL, error) {
+	if err := s.listenAndCheckEndpoint(); err != nil {
+		return nil, err
+	}
+
+	return s.endpoint, nil
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	if err := s.listenAndCheckEndpoint(); err != nil {
+		return err
+	}
+	s.baseCtx = ctx
+	log.Info("[http] server start", zap.String("listener", s.lis.Addr().String()), zap.Any("endpoint", s.endpoint))
+	var err error
+	if s.tlsConf != nil {
+		err = s.ServeTLS(s.lis, "", "")
+	} else {
+		err = s.Serve(s.lis)
+	}
+	if !stderrors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	log.Info("[http] server stopping")
+	return s.Shutdown(ctx)
+}
+
+func (s *Server) listenAndCheckEndpoint() error {
+	if s.lis == nil {
+		lis, err := net.Listen("tcp", s.address)
+		if err != nil {
+			return err
+		}
+		s.lis = lis
+	}
+	if s.endpoint == nil {
+		addr, err := host.Extract(s.address, s.lis)
+		if err != nil {
+			return err
+		}
+		scheme := "http"
+		if s.tlsConf != nil {
+			scheme = "https"
+		}
+		s.endpoint = &url.URL{
+			Scheme: scheme,
+			Host:   addr,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kanengo/ngrpc/errors"
+	"github.com/kanengo/ngrpc/middleware"
+	"github.com/kanengo/ngrpc/transport"
+)
+
+type testReply struct {
+	Message string `json:"message"`
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer(
+		Middleware(func(handler middleware.Handler) middleware.Handler {
+			return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+				if tr, ok := transport.FromServerContext(ctx); ok {
+					tr.ReplyHeader().Set("req_id", "3344")
+				}
+				return handler(ctx, req)
+			}
+		}),
+	)
+	srv.AddMiddleware("/helloworld.Greeter/SayHello", func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				tr.ReplyHeader().Set("full_method", tr.FullMethod())
+				if tr.Kind() != transport.KindHTTP {
+					t.Errorf("expect %v, got %v", transport.KindHTTP, tr.Kind())
+				}
+			}
+			return handler(ctx, req)
+		}
+	})
+	srv.Route(http.MethodGet, "/helloworld/{name}", "/helloworld.Greeter/SayHello", func(ctx Context) error {
+		h := ctx.Middleware(func(ctx context.Context, req any) (any, error) {
+			name := req.(string)
+			if name == "error" {
+				return nil, errors.BadRequest(fmt.Sprintf("invalid argument %s", name)).WithReason("INVALID_NAME")
+			}
+			return &testReply{Message: fmt.Sprintf("Hello %s", name)}, nil
+		})
+		reply, err := h(ctx, ctx.Vars()["name"])
+		if err != nil {
+			return err
+		}
+		return ctx.Result(http.StatusOK, reply)
+	})
+	srv.HandleFunc("/raw", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("raw"))
+	})
+
+	if e, err := srv.Endpoint(); err != nil || e == nil || strings.HasSuffix(e.Host, ":0") {
+		t.Fatal(e, err)
+	}
+
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			panic(err)
+		}
+	}()
+	time.Sleep(time.Second)
+	testClient(t, srv)
+	_ = srv.Stop(ctx)
+}
+
+func testClient(t *testing.T, srv *Server) {
+	e, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := e.String()
+
+	resp, err := http.Get(base + "/helloworld/kratos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reply testReply
+	_ = json.NewDecoder(resp.Body).Decode(&reply)
+	_ = resp.Body.Close()
+	if !reflect.DeepEqual(reply.Message, "Hello kratos") {
+		t.Errorf("expect %s, got %s", "Hello kratos", reply.Message)
+	}
+	if resp.Header.Get("req_id") != "3344" || resp.Header.Get("full_method") != "/helloworld.Greeter/SayHello" {
+		t.Errorf("expect reply header, got %v", resp.Header)
+	}
+
+	resp, err = http.Get(base + "/helloworld/error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expect %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+	var st map[string]any
+	_ = json.Unmarshal(data, &st)
+	if st["reason"] != "INVALID_NAME" {
+		t.Errorf("expect %s, got %s", "INVALID_NAME", data)
+	}
+
+	resp, err = http.Post(base+"/helloworld/kratos", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expect %d, got %d", http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+
+	resp, err = http.Get(base + "/notfound")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expect %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+
+	resp, err = http.Get(base + "/raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(data) != "raw" {
+		t.Errorf("expect %s, got %s", "raw", data)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	o := &Server{}
+	v := time.Duration(123)
+	Timeout(v)(o)
+	if !reflect.DeepEqual(v, o.timeout) {
+		t.Errorf("expect %s, got %s", v, o.timeout)
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	o := &Server{}
+	v := &tls.Config{}
+	TLSConfig(v)(o)
+	if !reflect.DeepEqual(v, o.tlsConf) {
+		t.Errorf("expect %v, got %v", v, o.tlsConf)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	srv := NewServer(MaxBodySize(16))
+	srv.Route(http.MethodPost, "/echo", "/test.Echo/Echo", func(ctx Context) error {
+		var in testReply
+		if err := ctx.Bind(&in); err != nil {
+			return err
+		}
+		return ctx.Result(http.StatusOK, &in)
+	})
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"message":"hi"}`, http.StatusOK},
+		{`{"message":"hello world"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(test.body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%s: expect %d, got %d %s", test.body, test.code, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/kanengo/ngrpc/transport"
+)
+
+var _ transport.Transporter = (*Transport)(nil)
+
+type Transport struct {
+	endpoint     string
+	fullMethod   string
+	pathTemplate string
+	reqHeader    headerCarrier
+	replyHeader  headerCarrier
+	request      *http.Request
+}
+
+func (t *Transport) Kind() transport.Kind {
+	return transport.KindHTTP
+}
+
+func (t *Transport) Endpoint() string {
+	return t.endpoint
+}
+
+// FullMethod returns the operation of the matched route,
+// it is the path template when the route has no operation.
+func (t *Transport) FullMethod() string {
+	return t.fullMethod
+}
+
+func (t *Transport) RequestHeader() transport.Header {
+	return t.reqHeader
+}
+
+func (t *Transport) ReplyHeader() transport.Header {
+	return t.replyHeader
+}
+
+func (t *Transport) PathTemplate() string {
+	return t.pathTemplate
+}
+
+func (t *Transport) Request() *http.Request {
+	return t.request
+}
+
+type headerCarrier http.Header
+
+func (h headerCarrier) Get(key string) string {
+	return http.Header(h).Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	http.Header(h).Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -2,9 +2,15 @@ package transport
 
 import (
 	"context"
+	"net/url"
 )
 
 type Server interface {
 	Start(context.Context) error
 	Stop(context.Context) error
 }
+
+// Endpointer is implemented by servers registered to the service registry.
+type Endpointer interface {
+	Endpoint() (*url.URL, error)
+}